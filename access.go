@@ -0,0 +1,230 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// ErrAccessDenied is returned, if a user is not allowed to access a workspace
+var ErrAccessDenied = errors.New("Access denied")
+
+// RepositoryAccessTTL specifies how long the visibility of a repository to a user is cached
+const RepositoryAccessTTL = 5 * time.Minute
+
+type repositoryAccessKey struct {
+	UserID       int64
+	RepositoryID int64
+}
+
+type repositoryAccessEntry struct {
+	Visible bool
+	Expires time.Time
+}
+
+// repositoryAccess caches, whether a repository is visible to a user
+var repositoryAccess map[repositoryAccessKey]repositoryAccessEntry
+var repositoryAccessMutex sync.Mutex
+
+// repositoryAccessPruned is the time expired entries were last removed from the cache
+var repositoryAccessPruned time.Time
+
+func init() {
+	repositoryAccess = make(map[repositoryAccessKey]repositoryAccessEntry)
+}
+
+// CanAccessRepository checks, whether the repository is visible to the user of the clients
+func (app *Application) CanAccessRepository(clients *GitHubClients, repositoryID int64) (visible bool, err error) {
+	var resp *github.Response
+
+	key := repositoryAccessKey{clients.User.GetID(), repositoryID}
+
+	repositoryAccessMutex.Lock()
+	entry, found := repositoryAccess[key]
+	repositoryAccessMutex.Unlock()
+
+	if found && time.Now().Before(entry.Expires) {
+		return entry.Visible, nil
+	}
+
+	if _, resp, err = clients.V3.Repositories.GetByID(context.Background(), repositoryID); err != nil {
+		if resp == nil || resp.StatusCode != 404 {
+			return false, fmt.Errorf("Could not retrieve repository %d: %w", repositoryID, err)
+		}
+	}
+
+	visible = err == nil
+
+	now := time.Now()
+
+	repositoryAccessMutex.Lock()
+	repositoryAccess[key] = repositoryAccessEntry{visible, now.Add(RepositoryAccessTTL)}
+	pruneRepositoryAccess(now)
+	repositoryAccessMutex.Unlock()
+
+	return visible, nil
+}
+
+// pruneRepositoryAccess removes expired entries from the cache, so that it does not grow with every
+// user and repository ever checked. Entries can only expire after the TTL, so the cache is pruned
+// at most once per TTL. The mutex needs to be locked by the caller
+func pruneRepositoryAccess(now time.Time) {
+	if now.Before(repositoryAccessPruned.Add(RepositoryAccessTTL)) {
+		return
+	}
+
+	for key, entry := range repositoryAccess {
+		if !now.Before(entry.Expires) {
+			delete(repositoryAccess, key)
+		}
+	}
+
+	repositoryAccessPruned = now
+}
+
+// CanPushRepositories checks, whether the user of the clients can push to all specified
+// repositories. Actions running with the installation clients of our app on behalf of a user
+// must check this first, since being a member of a workspace does not grant any rights on its
+// repositories
+func (app *Application) CanPushRepositories(clients *GitHubClients, repositoryIDs []int64) (bool, error) {
+	for _, repositoryID := range repositoryIDs {
+		repo, resp, err := clients.V3.Repositories.GetByID(context.Background(), repositoryID)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 {
+				return false, nil
+			}
+
+			return false, fmt.Errorf("Could not retrieve repository %d: %w", repositoryID, err)
+		}
+
+		if !repo.GetPermissions()["push"] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Permissions of users in a repository, from the least to the most privileged
+const (
+	PermissionNone     = "none"
+	PermissionRead     = "read"
+	PermissionTriage   = "triage"
+	PermissionWrite    = "write"
+	PermissionMaintain = "maintain"
+	PermissionAdmin    = "admin"
+)
+
+var permissionRanks = map[string]int{
+	PermissionNone:     0,
+	PermissionRead:     1,
+	PermissionTriage:   2,
+	PermissionWrite:    3,
+	PermissionMaintain: 4,
+	PermissionAdmin:    5,
+}
+
+// repositoryPermission is the permission of a collaborator. The role name contains the triage and
+// maintain roles, which the permission reports as read and write
+type repositoryPermission struct {
+	Permission string `json:"permission"`
+	RoleName   string `json:"role_name"`
+}
+
+// Level returns the most specific permission level known to us
+func (p *repositoryPermission) Level() string {
+	if _, ok := permissionRanks[p.RoleName]; ok {
+		return p.RoleName
+	}
+
+	if _, ok := permissionRanks[p.Permission]; ok {
+		return p.Permission
+	}
+
+	return PermissionNone
+}
+
+// PermissionAtLeast checks, whether the permission level grants at least the required permission
+func PermissionAtLeast(level string, required string) bool {
+	return permissionRanks[level] >= permissionRanks[required]
+}
+
+// HasRepositoryPermission checks, whether the user with the specified login has at least the
+// required permission in the repository. This is used to authorize commands in comments, which
+// are executed using the installation clients of our app
+func HasRepositoryPermission(clients *GitHubClients, repo *github.Repository, login string, required string) (bool, error) {
+	var permission repositoryPermission
+
+	// go-github does not know the role name yet, so we need to do the request ourselves
+	req, err := clients.V3.NewRequest("GET", fmt.Sprintf("repos/%s/%s/collaborators/%s/permission", repo.GetOwner().GetLogin(), repo.GetName(), login), nil)
+	if err != nil {
+		return false, err
+	}
+
+	if resp, err := clients.V3.Do(context.Background(), req, &permission); err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("Could not retrieve permission of %s in %s: %w", login, repo.GetFullName(), err)
+	}
+
+	return PermissionAtLeast(permission.Level(), required), nil
+}
+
+// CanAccessWorkspace checks, whether all repositories of the workspace are visible to the user
+// of the clients
+func (app *Application) CanAccessWorkspace(clients *GitHubClients, workspace *Workspace) (bool, error) {
+	for _, repositoryID := range workspace.RepositoryIDs {
+		visible, err := app.CanAccessRepository(clients, repositoryID)
+		if err != nil || !visible {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// GetAccessibleWorkspaces returns all workspaces the user of the clients can access
+func (app *Application) GetAccessibleWorkspaces(clients *GitHubClients) (accessible []*Workspace, err error) {
+	var (
+		workspaces []*Workspace
+		role       Role
+	)
+
+	if workspaces, err = app.db.GetWorkspaces(nil); err != nil {
+		return nil, err
+	}
+
+	accessible = []*Workspace{}
+
+	for _, workspace := range workspaces {
+		if role, err = app.GetRole(clients, workspace); err != nil {
+			return nil, err
+		}
+
+		if role != RoleNone {
+			accessible = append(accessible, workspace)
+		}
+	}
+
+	return accessible, nil
+}
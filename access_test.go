@@ -0,0 +1,80 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func TestHasRepositoryPermission(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/collaborators/triager/permission":
+			fmt.Fprint(w, `{"permission": "read", "role_name": "triage"}`)
+		case "/repos/owner/repo/collaborators/reader/permission":
+			fmt.Fprint(w, `{"permission": "read", "role_name": "read"}`)
+		case "/repos/owner/repo/collaborators/writer/permission":
+			fmt.Fprint(w, `{"permission": "write"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	clients := &GitHubClients{V3: client}
+	login, name := "owner", "repo"
+	repo := &github.Repository{Owner: &github.User{Login: &login}, Name: &name}
+
+	tests := []struct {
+		login    string
+		required string
+		expected bool
+	}{
+		{"triager", PermissionTriage, true},
+		{"triager", PermissionWrite, false},
+		{"reader", PermissionTriage, false},
+		{"writer", PermissionTriage, true},
+		{"writer", PermissionWrite, true},
+		{"stranger", PermissionRead, false},
+	}
+
+	for _, test := range tests {
+		allowed, err := HasRepositoryPermission(clients, repo, test.login, test.required)
+		if err != nil {
+			t.Fatalf("Could not check permission of %s: %s", test.login, err)
+		}
+
+		if allowed != test.expected {
+			t.Errorf("Expected %s to have %s permission: %v, got %v", test.login, test.required, test.expected, allowed)
+		}
+	}
+}
+
+func TestPruneRepositoryAccess(t *testing.T) {
+	now := time.Now()
+
+	repositoryAccessMutex.Lock()
+	defer repositoryAccessMutex.Unlock()
+
+	repositoryAccess[repositoryAccessKey{1, 1}] = repositoryAccessEntry{true, now.Add(-time.Second)}
+	repositoryAccess[repositoryAccessKey{1, 2}] = repositoryAccessEntry{true, now.Add(time.Minute)}
+	repositoryAccessPruned = time.Time{}
+
+	pruneRepositoryAccess(now)
+
+	if _, found := repositoryAccess[repositoryAccessKey{1, 1}]; found {
+		t.Errorf("Expected expired entry to be removed")
+	}
+
+	if _, found := repositoryAccess[repositoryAccessKey{1, 2}]; !found {
+		t.Errorf("Expected valid entry to be kept")
+	}
+}
@@ -0,0 +1,533 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// Backlog contains the open issues of all repositories of a workspace, that are not yet planned
+// in a milestone
+type Backlog struct {
+	WorkspaceID int64           `json:"workspaceID"`
+	Issues      []*BacklogIssue `json:"issues"`
+}
+
+// BacklogIssue is an issue of one of the repositories of a workspace
+type BacklogIssue struct {
+	// ID is the global node ID of the issue
+	ID           string     `json:"id"`
+	RepositoryID int64      `json:"repositoryID"`
+	Repository   string     `json:"repository"`
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	State        string     `json:"state"`
+	Labels       []string   `json:"labels"`
+	Assignees    []string   `json:"assignees"`
+	Milestone    string     `json:"milestone,omitempty"`
+	Reactions    int        `json:"reactions"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	ClosedAt     *time.Time `json:"closedAt,omitempty"`
+	Rank         string     `json:"rank,omitempty"`
+	Estimate     *float64   `json:"estimate,omitempty"`
+	// Fields contains the values of the custom fields of the workspace by their name
+	Fields map[string]string `json:"fields,omitempty"`
+
+	PullRequests []*LinkedPullRequest `json:"pullRequests"`
+}
+
+// LinkedPullRequest is a pull request that references an issue
+type LinkedPullRequest struct {
+	Repository string `json:"repository"`
+	Number     int    `json:"number"`
+	URL        string `json:"url"`
+	State      string `json:"state"`
+}
+
+type pullRequestNode struct {
+	Number     int
+	URL        string
+	State      githubv4.PullRequestState
+	Repository struct {
+		NameWithOwner string
+	}
+}
+
+// issueNode contains all fields of an issue we query using the GraphQL API
+type issueNode struct {
+	ID         githubv4.ID
+	Number     int
+	Title      string
+	URL        string
+	State      githubv4.IssueState
+	CreatedAt  githubv4.DateTime
+	UpdatedAt  githubv4.DateTime
+	ClosedAt   *githubv4.DateTime
+	Repository struct {
+		DatabaseID    int64
+		NameWithOwner string
+	}
+	Labels struct {
+		Nodes []struct {
+			Name string
+		}
+	} `graphql:"labels(first: 20)"`
+	Assignees struct {
+		Nodes []struct {
+			Login string
+		}
+	} `graphql:"assignees(first: 10)"`
+	Milestone *struct {
+		Title string
+	}
+	Reactions struct {
+		TotalCount int
+	}
+	TimelineItems struct {
+		Nodes []struct {
+			CrossReferencedEvent struct {
+				Source struct {
+					PullRequest pullRequestNode `graphql:"... on PullRequest"`
+				}
+			} `graphql:"... on CrossReferencedEvent"`
+			ConnectedEvent struct {
+				Subject struct {
+					PullRequest pullRequestNode `graphql:"... on PullRequest"`
+				}
+			} `graphql:"... on ConnectedEvent"`
+		}
+	} `graphql:"timelineItems(first: 20, itemTypes: [CROSS_REFERENCED_EVENT, CONNECTED_EVENT])"`
+}
+
+func (n *issueNode) toBacklogIssue() *BacklogIssue {
+	issue := &BacklogIssue{
+		ID:           fmt.Sprintf("%v", n.ID),
+		RepositoryID: n.Repository.DatabaseID,
+		Repository:   n.Repository.NameWithOwner,
+		Number:       n.Number,
+		Title:        n.Title,
+		URL:          n.URL,
+		State:        strings.ToLower(string(n.State)),
+		Labels:       []string{},
+		Assignees:    []string{},
+		Reactions:    n.Reactions.TotalCount,
+		CreatedAt:    n.CreatedAt.Time,
+		UpdatedAt:    n.UpdatedAt.Time,
+		PullRequests: []*LinkedPullRequest{},
+	}
+
+	if n.ClosedAt != nil {
+		issue.ClosedAt = &n.ClosedAt.Time
+	}
+
+	if n.Milestone != nil {
+		issue.Milestone = n.Milestone.Title
+	}
+
+	for _, label := range n.Labels.Nodes {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+
+	for _, assignee := range n.Assignees.Nodes {
+		issue.Assignees = append(issue.Assignees, assignee.Login)
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range n.TimelineItems.Nodes {
+		for _, pull := range []pullRequestNode{item.CrossReferencedEvent.Source.PullRequest, item.ConnectedEvent.Subject.PullRequest} {
+			if pull.URL == "" || seen[pull.URL] {
+				continue
+			}
+
+			seen[pull.URL] = true
+
+			issue.PullRequests = append(issue.PullRequests, &LinkedPullRequest{
+				Repository: pull.Repository.NameWithOwner,
+				Number:     pull.Number,
+				URL:        pull.URL,
+				State:      strings.ToLower(string(pull.State)),
+			})
+		}
+	}
+
+	return issue
+}
+
+// GetRepositories resolves the repositories with the specified IDs using the supplied clients
+func (app *Application) GetRepositories(clients *GitHubClients, repositoryIDs []int64) (repositories []*github.Repository, err error) {
+	var repo *github.Repository
+
+	for _, repositoryID := range repositoryIDs {
+		if repo, _, err = clients.V3.Repositories.GetByID(context.Background(), repositoryID); err != nil {
+			return nil, fmt.Errorf("Could not retrieve repository %d: %w", repositoryID, err)
+		}
+
+		repositories = append(repositories, repo)
+	}
+
+	return
+}
+
+// GetBacklog retrieves the open issues from all repositories of the workspace that do not have
+// a milestone associated with it. The repositories are queried concurrently and the issues are
+// returned in the order of their backlog rank
+func (app *Application) GetBacklog(clients *GitHubClients, workspaceID int64) (backlog *Backlog, err error) {
+	var (
+		workspace *Workspace
+		issues    []*BacklogIssue
+	)
+
+	if workspace, err = app.GetWorkspace(workspaceID); err != nil {
+		return nil, err
+	}
+
+	if workspace == nil {
+		return nil, fmt.Errorf("%w: workspace %d does not exist", ErrValidationFailed, workspaceID)
+	}
+
+	// issues without a milestone cannot be filtered, since a null milestone is omitted from the
+	// filter, so all open issues are retrieved and the unplanned ones are selected below
+	filter := githubv4.IssueFilters{
+		States: &[]githubv4.IssueState{githubv4.IssueStateOpen},
+	}
+
+	if issues, err = app.QueryWorkspaceIssues(clients, workspace, filter); err != nil {
+		return nil, err
+	}
+
+	backlog = &Backlog{WorkspaceID: workspace.ID, Issues: []*BacklogIssue{}}
+
+	for _, issue := range issues {
+		if issue.Milestone == "" {
+			backlog.Issues = append(backlog.Issues, issue)
+		}
+	}
+
+	// new issues are ranked in the order of their creation
+	sort.SliceStable(backlog.Issues, func(i, j int) bool {
+		return backlog.Issues[i].CreatedAt.Before(backlog.Issues[j].CreatedAt)
+	})
+
+	if err = app.rankBacklog(backlog); err != nil {
+		return nil, err
+	}
+
+	return backlog, nil
+}
+
+// BacklogStream iterates over the backlog issues matching a streamable query in the order of the
+// query. The repositories are queried page by page in that order and their pages are merged, so
+// that at most one page of issues per repository is held in memory
+type BacklogStream struct {
+	query  *BacklogQuery
+	login  string
+	pagers []*issuePager
+}
+
+// issuePager holds the current page of issues of a repository and fetches the next page, once all
+// of its issues have been consumed
+type issuePager struct {
+	issues []*BacklogIssue
+	last   bool
+	fetch  func() (issues []*BacklogIssue, last bool, err error)
+}
+
+// head returns the next issue of the repository or nil, if all of its issues have been consumed
+func (p *issuePager) head() (issue *BacklogIssue, err error) {
+	for len(p.issues) == 0 && !p.last {
+		if p.issues, p.last, err = p.fetch(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(p.issues) == 0 {
+		return nil, nil
+	}
+
+	return p.issues[0], nil
+}
+
+// StreamBacklog returns a stream of the backlog issues of the workspace, which match the query.
+// Only streamable queries are supported, see BacklogQuery.Streamable. Issues are annotated page by
+// page. Issues that have not been ranked yet have no rank, since they are only ranked by GetBacklog.
+// The first page of each repository is retrieved right away, so that errors are returned before
+// anything has been written
+func (app *Application) StreamBacklog(clients *GitHubClients, workspace *Workspace, query *BacklogQuery, login string) (stream *BacklogStream, err error) {
+	var (
+		repositories []*github.Repository
+		ranks        []*BacklogRank
+	)
+
+	if !query.Streamable() {
+		return nil, fmt.Errorf("%w: issues sorted by %s cannot be streamed", ErrValidationFailed, query.SortField)
+	}
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return nil, err
+	}
+
+	if ranks, err = app.db.GetBacklogRanks("workspace_id = ?", workspace.ID); err != nil {
+		return nil, fmt.Errorf("Could not fetch backlog ranks from database: %w", err)
+	}
+
+	byIssue := make(map[string]string)
+	for _, r := range ranks {
+		byIssue[r.IssueID] = r.Rank
+	}
+
+	filter := githubv4.IssueFilters{
+		States: &[]githubv4.IssueState{githubv4.IssueStateOpen},
+	}
+
+	order := query.issueOrder()
+
+	stream = &BacklogStream{query: query, login: login}
+
+	for _, repo := range repositories {
+		var (
+			repo   = repo
+			cursor *githubv4.String
+		)
+
+		pager := &issuePager{fetch: func() (issues []*BacklogIssue, last bool, err error) {
+			if issues, cursor, err = queryIssuesPage(clients, repo, filter, order, cursor); err != nil {
+				return nil, false, err
+			}
+
+			for _, issue := range issues {
+				issue.Rank = byIssue[issue.ID]
+			}
+
+			if err = app.annotateIssues(workspace.ID, issues); err != nil {
+				return nil, false, err
+			}
+
+			return issues, cursor == nil, nil
+		}}
+
+		if _, err = pager.head(); err != nil {
+			return nil, err
+		}
+
+		stream.pagers = append(stream.pagers, pager)
+	}
+
+	return stream, nil
+}
+
+// Next returns the next issue of the stream or nil, if all issues have been returned
+func (s *BacklogStream) Next() (*BacklogIssue, error) {
+	for {
+		var (
+			next  *issuePager
+			first *BacklogIssue
+		)
+
+		for _, pager := range s.pagers {
+			issue, err := pager.head()
+			if err != nil {
+				return nil, err
+			}
+
+			if issue != nil && (first == nil || s.query.Less(issue, first)) {
+				next, first = pager, issue
+			}
+		}
+
+		if next == nil {
+			return nil, nil
+		}
+
+		next.issues = next.issues[1:]
+
+		// issues of a milestone are not part of the backlog
+		if first.Milestone == "" && s.query.Matches(first, s.login) {
+			return first, nil
+		}
+	}
+}
+
+// QueryWorkspaceIssues retrieves the issues matching the filter from all repositories of the
+// workspace. The repositories are queried concurrently
+func (app *Application) QueryWorkspaceIssues(clients *GitHubClients, workspace *Workspace, filter githubv4.IssueFilters) (issues []*BacklogIssue, err error) {
+	var (
+		repositories []*github.Repository
+	)
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	var (
+		wg      sync.WaitGroup
+		results = make([][]*BacklogIssue, len(repositories))
+		errs    = make([]error, len(repositories))
+	)
+
+	for i, repo := range repositories {
+		wg.Add(1)
+
+		go func(i int, repo *github.Repository) {
+			defer wg.Done()
+
+			results[i], errs[i] = app.queryIssues(clients, repo, filter)
+		}(i, repo)
+	}
+
+	wg.Wait()
+
+	log.Infof("call to GitHub took %+v", time.Since(start))
+
+	issues = []*BacklogIssue{}
+
+	for i := range repositories {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		issues = append(issues, results[i]...)
+	}
+
+	if err = app.annotateIssues(workspace.ID, issues); err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// annotateIssues adds the information stored in the database to the issues of the workspace
+func (app *Application) annotateIssues(workspaceID int64, issues []*BacklogIssue) (err error) {
+	if err = app.estimateIssues(issues); err != nil {
+		return err
+	}
+
+	return app.setCustomFieldValues(workspaceID, issues)
+}
+
+// queryIssues retrieves all issues of a repository that match the filter using the GraphQL API.
+// All pages are retrieved, one query per page
+func (app *Application) queryIssues(clients *GitHubClients, repo *github.Repository, filter githubv4.IssueFilters) (issues []*BacklogIssue, err error) {
+	var (
+		page   []*BacklogIssue
+		cursor *githubv4.String
+	)
+
+	for {
+		if page, cursor, err = queryIssuesPage(clients, repo, filter, nil, cursor); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, page...)
+
+		if cursor == nil {
+			return issues, nil
+		}
+	}
+}
+
+// queryIssuesPage retrieves one page of issues of a repository that match the filter in the
+// specified order, starting after the cursor. The returned cursor is nil after the last page
+func queryIssuesPage(clients *GitHubClients, repo *github.Repository, filter githubv4.IssueFilters, order *githubv4.IssueOrder, cursor *githubv4.String) (issues []*BacklogIssue, next *githubv4.String, err error) {
+	var q struct {
+		Repository struct {
+			Issues struct {
+				Nodes    []issueNode
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"issues(first: 100, after: $cursor, filterBy: $filterBy, orderBy: $orderBy)"`
+		} `graphql:"repository(owner: $repositoryOwner, name: $repositoryName)"`
+	}
+
+	variables := map[string]interface{}{
+		"repositoryOwner": githubv4.String(repo.GetOwner().GetLogin()),
+		"repositoryName":  githubv4.String(repo.GetName()),
+		"filterBy":        filter,
+		"orderBy":         order,
+		"cursor":          cursor,
+	}
+
+	if err = clients.V4.Query(context.Background(), &q, variables); err != nil {
+		return nil, nil, fmt.Errorf("Could not query issues of repository %s: %w", repo.GetFullName(), err)
+	}
+
+	for _, node := range q.Repository.Issues.Nodes {
+		issues = append(issues, node.toBacklogIssue())
+	}
+
+	if q.Repository.Issues.PageInfo.HasNextPage {
+		next = githubv4.NewString(q.Repository.Issues.PageInfo.EndCursor)
+	}
+
+	return issues, next, nil
+}
+
+// GetIssuesByID retrieves the issues with the specified global node IDs using the GraphQL API.
+// IDs which do not refer to an issue are ignored
+func (app *Application) GetIssuesByID(clients *GitHubClients, ids []string) (issues []*BacklogIssue, err error) {
+	issues = []*BacklogIssue{}
+
+	// GitHub allows at most 100 nodes per query
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var q struct {
+			Nodes []struct {
+				Issue issueNode `graphql:"... on Issue"`
+			} `graphql:"nodes(ids: $ids)"`
+		}
+
+		var chunk []githubv4.ID
+		for _, id := range ids[start:end] {
+			chunk = append(chunk, githubv4.ID(id))
+		}
+
+		if err = clients.V4.Query(context.Background(), &q, map[string]interface{}{
+			"ids": chunk,
+		}); err != nil {
+			return nil, fmt.Errorf("Could not query issues by ID: %w", err)
+		}
+
+		for _, node := range q.Nodes {
+			if node.Issue.ID == nil {
+				continue
+			}
+
+			issues = append(issues, node.Issue.toBacklogIssue())
+		}
+	}
+
+	if err = app.estimateIssues(issues); err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,69 @@
+package issues
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBacklogStream(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2020, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	// pages of two repositories, each sorted by creation as retrieved from GitHub
+	pages := [][][]*BacklogIssue{
+		{
+			{{Number: 1, CreatedAt: day(1)}, {Number: 4, CreatedAt: day(4)}},
+			{{Number: 6, CreatedAt: day(6), Milestone: "v1.0"}, {Number: 7, CreatedAt: day(7)}},
+		},
+		{
+			{{Number: 2, CreatedAt: day(2), Labels: []string{"wontfix"}}, {Number: 3, CreatedAt: day(3)}},
+			{},
+			{{Number: 5, CreatedAt: day(5)}},
+		},
+	}
+
+	query, err := ParseBacklogQuery("-label:wontfix sort:created-asc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	stream := &BacklogStream{query: query}
+
+	for _, repo := range pages {
+		repo := repo
+		stream.pagers = append(stream.pagers, &issuePager{fetch: func() ([]*BacklogIssue, bool, error) {
+			page := repo[0]
+			repo = repo[1:]
+
+			return page, len(repo) == 0, nil
+		}})
+	}
+
+	var numbers []int
+	for {
+		issue, err := stream.Next()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if issue == nil {
+			break
+		}
+
+		numbers = append(numbers, issue.Number)
+	}
+
+	expected := []int{1, 3, 4, 5, 7}
+
+	if len(numbers) != len(expected) {
+		t.Fatalf("Expected issues %v, got %v", expected, numbers)
+	}
+
+	for i := range expected {
+		if numbers[i] != expected[i] {
+			t.Errorf("Expected issues %v, got %v", expected, numbers)
+			break
+		}
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	// StateOpen is the state of open issues
+	StateOpen = "open"
+	// StateClosed is the state of closed issues
+	StateClosed = "closed"
+)
+
+// BoardClosedDays specifies how long closed issues are shown on a board
+const BoardClosedDays = 14
+
+// BoardColumn is a column of the Kanban board of a workspace. An issue belongs to a column if it
+// has the label of the column. Columns without a label contain all issues of the specified state,
+// that do not belong to any other column
+type BoardColumn struct {
+	ID          int64  `json:"id"`
+	WorkspaceID int64  `json:"workspaceID"`
+	Position    int    `json:"position"`
+	Name        string `json:"name"`
+	Label       string `json:"label,omitempty"`
+	State       string `json:"state,omitempty"`
+	// WIPLimit is the maximum number of issues in the column, 0 means unlimited
+	WIPLimit int `json:"wipLimit"`
+}
+
+// Board contains the columns of a workspace with the issues grouped by column
+type Board struct {
+	WorkspaceID int64                `json:"workspaceID"`
+	Columns     []*BoardColumnIssues `json:"columns"`
+}
+
+// BoardColumnIssues is a column of a board together with its issues
+type BoardColumnIssues struct {
+	*BoardColumn
+	Issues    []*BacklogIssue `json:"issues"`
+	OverLimit bool            `json:"overLimit"`
+}
+
+// ErrWIPLimitReached is returned, if an issue is moved into a column that already has reached its WIP limit
+var ErrWIPLimitReached = errors.New("WIP limit reached")
+
+// Validate checks, whether the column can be stored
+func (c *BoardColumn) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Label = strings.TrimSpace(c.Label)
+
+	if c.Name == "" {
+		return fmt.Errorf("%w: column name must not be empty", ErrValidationFailed)
+	}
+
+	if c.Label != "" && c.State != "" {
+		return fmt.Errorf("%w: column %s can either be defined by a label or a state", ErrValidationFailed, c.Name)
+	}
+
+	if c.Label == "" && c.State != StateOpen && c.State != StateClosed {
+		return fmt.Errorf("%w: column %s needs a label or the state %s or %s", ErrValidationFailed, c.Name, StateOpen, StateClosed)
+	}
+
+	if c.WIPLimit < 0 || (c.WIPLimit > 0 && c.Label == "") {
+		return fmt.Errorf("%w: column %s can only have a positive WIP limit if it is defined by a label", ErrValidationFailed, c.Name)
+	}
+
+	return nil
+}
+
+// Matches checks, whether the issue belongs to the column according to the label or state of the column
+func (c *BoardColumn) Matches(issue *BacklogIssue) bool {
+	if c.Label != "" {
+		return issue.State == StateOpen && containsFold(issue.Labels, c.Label)
+	}
+
+	return issue.State == c.State
+}
+
+// GetBoardColumns returns the columns of the workspace's board in the order of their position
+func (app *Application) GetBoardColumns(workspaceID int64) (columns []*BoardColumn, err error) {
+	if columns, err = app.db.GetBoardColumns("workspace_id = ?", workspaceID); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(columns, func(i, j int) bool {
+		return columns[i].Position < columns[j].Position
+	})
+
+	return columns, nil
+}
+
+// SetBoardColumns replaces the columns of the workspace's board. The position of the columns is
+// determined by their order. Columns with the ID of an existing column are updated, so that their
+// ID stays the same, columns without an ID are created and missing columns are deleted
+func (app *Application) SetBoardColumns(workspaceID int64, columns []*BoardColumn) (err error) {
+	var existing []*BoardColumn
+
+	if existing, err = app.GetBoardColumns(workspaceID); err != nil {
+		return err
+	}
+
+	keep := make(map[int64]bool)
+	for _, column := range existing {
+		keep[column.ID] = false
+	}
+
+	for i, column := range columns {
+		if err = column.Validate(); err != nil {
+			return err
+		}
+
+		if kept, ok := keep[column.ID]; column.ID != 0 && !ok {
+			return fmt.Errorf("%w: column %d does not belong to the board", ErrValidationFailed, column.ID)
+		} else if column.ID != 0 && kept {
+			return fmt.Errorf("%w: column %d is contained twice", ErrValidationFailed, column.ID)
+		}
+
+		keep[column.ID] = true
+		column.WorkspaceID = workspaceID
+		column.Position = i
+	}
+
+	return app.db.Transaction(func(tx Database) (err error) {
+		for id, kept := range keep {
+			if !kept && id != 0 {
+				if err = tx.Delete(&BoardColumn{ID: id}); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, column := range columns {
+			if column.ID == 0 {
+				err = tx.Insert(column)
+			} else {
+				_, err = tx.Update(column)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetBoard returns the board of the workspace. It contains the open issues of all repositories of
+// the workspace and the ones closed within the last BoardClosedDays days
+func (app *Application) GetBoard(clients *GitHubClients, workspace *Workspace) (board *Board, err error) {
+	var (
+		columns []*BoardColumn
+		issues  []*BacklogIssue
+		closed  []*BacklogIssue
+	)
+
+	if columns, err = app.GetBoardColumns(workspace.ID); err != nil {
+		return nil, err
+	}
+
+	board = &Board{WorkspaceID: workspace.ID, Columns: []*BoardColumnIssues{}}
+
+	for _, column := range columns {
+		board.Columns = append(board.Columns, &BoardColumnIssues{BoardColumn: column, Issues: []*BacklogIssue{}})
+	}
+
+	if len(columns) == 0 {
+		return board, nil
+	}
+
+	if issues, err = app.QueryWorkspaceIssues(clients, workspace, githubv4.IssueFilters{
+		States: &[]githubv4.IssueState{githubv4.IssueStateOpen},
+	}); err != nil {
+		return nil, err
+	}
+
+	if hasClosedColumn(columns) {
+		since := githubv4.DateTime{Time: time.Now().AddDate(0, 0, -BoardClosedDays)}
+
+		// the filter only restricts the update time, which includes all recently closed issues,
+		// but also old ones with new comments
+		if closed, err = app.QueryWorkspaceIssues(clients, workspace, githubv4.IssueFilters{
+			States: &[]githubv4.IssueState{githubv4.IssueStateClosed},
+			Since:  &since,
+		}); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, closedSince(closed, since.Time)...)
+	}
+
+	for _, issue := range issues {
+		if column := board.column(issue); column != nil {
+			column.Issues = append(column.Issues, issue)
+		}
+	}
+
+	for _, column := range board.Columns {
+		column.OverLimit = column.WIPLimit > 0 && len(column.Issues) > column.WIPLimit
+	}
+
+	return board, nil
+}
+
+// closedSince returns the issues closed after the specified time
+func closedSince(issues []*BacklogIssue, since time.Time) (closed []*BacklogIssue) {
+	for _, issue := range issues {
+		if issue.ClosedAt != nil && issue.ClosedAt.After(since) {
+			closed = append(closed, issue)
+		}
+	}
+
+	return closed
+}
+
+// column returns the column the issue belongs to. Label columns take precedence over state columns
+func (board *Board) column(issue *BacklogIssue) *BoardColumnIssues {
+	for _, column := range board.Columns {
+		if column.Label != "" && column.Matches(issue) {
+			return column
+		}
+	}
+
+	for _, column := range board.Columns {
+		if column.Label == "" && column.Matches(issue) {
+			return column
+		}
+	}
+
+	return nil
+}
+
+func hasClosedColumn(columns []*BoardColumn) bool {
+	for _, column := range columns {
+		if column.State == StateClosed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MoveBoardIssue moves an issue to another column of the workspace's board by swapping the labels
+// of the columns or changing the state of the issue. The clients should be the ones of the user
+// moving the card, so that GitHub checks their permissions
+func (app *Application) MoveBoardIssue(clients *GitHubClients, workspace *Workspace, repository string, number int, columnID int64) (err error) {
+	var (
+		columns []*BoardColumn
+		target  *BoardColumn
+		repo    *github.Repository
+		issue   *github.Issue
+	)
+
+	if columns, err = app.GetBoardColumns(workspace.ID); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if column.ID == columnID {
+			target = column
+		}
+	}
+
+	if target == nil {
+		return fmt.Errorf("%w: column %d does not exist", ErrValidationFailed, columnID)
+	}
+
+	if repo, issue, err = app.getWorkspaceIssue(clients, workspace, repository, number); err != nil {
+		return err
+	}
+
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	if target.WIPLimit > 0 && !hasLabel(issue, target.Label) {
+		if err = app.checkWIPLimit(clients, workspace, target); err != nil {
+			return err
+		}
+	}
+
+	// remove the labels of all other columns
+	for _, column := range columns {
+		if column.Label == "" || column.ID == target.ID || !hasLabel(issue, column.Label) {
+			continue
+		}
+
+		if _, err = clients.V3.Issues.RemoveLabelForIssue(context.Background(), owner, name, number, column.Label); err != nil {
+			return fmt.Errorf("Could not remove label %s from %s: %w", column.Label, GetIssueIdentifier(repo, issue), err)
+		}
+	}
+
+	if target.Label != "" && !hasLabel(issue, target.Label) {
+		if _, _, err = clients.V3.Issues.AddLabelsToIssue(context.Background(), owner, name, number, []string{target.Label}); err != nil {
+			return fmt.Errorf("Could not add label %s to %s: %w", target.Label, GetIssueIdentifier(repo, issue), err)
+		}
+	}
+
+	// label columns only contain open issues
+	state := target.State
+	if state == "" {
+		state = StateOpen
+	}
+
+	if issue.GetState() != state {
+		if _, _, err = clients.V3.Issues.Edit(context.Background(), owner, name, number, &github.IssueRequest{State: &state}); err != nil {
+			return fmt.Errorf("Could not change state of %s to %s: %w", GetIssueIdentifier(repo, issue), state, err)
+		}
+	}
+
+	log.Infof("Moved issue %s to column %s", GetIssueIdentifier(repo, issue), target.Name)
+
+	return nil
+}
+
+func hasLabel(issue *github.Issue, label string) bool {
+	for _, l := range issue.Labels {
+		if strings.EqualFold(l.GetName(), label) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkWIPLimit returns ErrWIPLimitReached, if no further issue can be added to the column
+func (app *Application) checkWIPLimit(clients *GitHubClients, workspace *Workspace, column *BoardColumn) (err error) {
+	var count int
+
+	if count, err = app.CountColumnIssues(clients, workspace, column); err != nil {
+		return err
+	}
+
+	if count >= column.WIPLimit {
+		return fmt.Errorf("%w: column %s already contains %d of %d issues", ErrWIPLimitReached, column.Name, count, column.WIPLimit)
+	}
+
+	return nil
+}
+
+// CountColumnIssues counts the open issues of all workspace repositories with the label of the
+// column using the search API
+func (app *Application) CountColumnIssues(clients *GitHubClients, workspace *Workspace, column *BoardColumn) (count int, err error) {
+	var (
+		repositories []*github.Repository
+		result       *github.IssuesSearchResult
+	)
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("is:issue is:open label:%q", column.Label)
+	for _, repo := range repositories {
+		query += fmt.Sprintf(" repo:%s", repo.GetFullName())
+	}
+
+	if result, _, err = clients.V3.Search.Issues(context.Background(), query, &github.SearchOptions{}); err != nil {
+		return 0, fmt.Errorf("Could not search for issues in column %s: %w", column.Name, err)
+	}
+
+	return result.GetTotal(), nil
+}
+
+// WarnWIPLimits comments on an issue, if it was labeled into a column that exceeds its WIP limit
+// in any of the workspaces containing its repository
+func (app *Application) WarnWIPLimits(clients *GitHubClients, repo *github.Repository, issue *github.Issue, label string) (err error) {
+	var (
+		workspaces []*Workspace
+		columns    []*BoardColumn
+		count      int
+	)
+
+	if workspaces, err = app.db.GetWorkspaces("? = ANY(repository_ids)", repo.GetID()); err != nil {
+		return fmt.Errorf("Could not fetch workspaces from database: %w", err)
+	}
+
+	for _, workspace := range workspaces {
+		if columns, err = app.GetBoardColumns(workspace.ID); err != nil {
+			return err
+		}
+
+		for _, column := range columns {
+			if column.WIPLimit == 0 || !strings.EqualFold(column.Label, label) {
+				continue
+			}
+
+			if count, err = app.CountColumnIssues(clients, workspace, column); err != nil {
+				return err
+			}
+
+			if count <= column.WIPLimit {
+				continue
+			}
+
+			body := fmt.Sprintf("Column **%s** of workspace **%s** is over its WIP limit with %d of %d issues. Please consider finishing other issues first.", column.Name, workspace.Name, count, column.WIPLimit)
+
+			if _, _, err = clients.V3.Issues.CreateComment(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueComment{
+				Body: &body,
+			}); err != nil {
+				return fmt.Errorf("Creating comment for issue %s failed: %w", GetIssueIdentifier(repo, issue), err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package issues
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoardColumn(t *testing.T) {
+	board := &Board{Columns: []*BoardColumnIssues{
+		{BoardColumn: &BoardColumn{Name: "To Do", State: StateOpen}},
+		{BoardColumn: &BoardColumn{Name: "In Progress", Label: "status:doing"}},
+		{BoardColumn: &BoardColumn{Name: "Done", State: StateClosed}},
+	}}
+
+	var tests = []struct {
+		issue  *BacklogIssue
+		column string
+	}{
+		{&BacklogIssue{State: StateOpen, Labels: []string{"bug"}}, "To Do"},
+		{&BacklogIssue{State: StateOpen, Labels: []string{"Status:Doing"}}, "In Progress"},
+		{&BacklogIssue{State: StateClosed, Labels: []string{"status:doing"}}, "Done"},
+	}
+
+	for _, test := range tests {
+		if column := board.column(test.issue); column == nil || column.Name != test.column {
+			t.Errorf("Expected issue %+v in column %s, got %+v", test.issue, test.column, column)
+		}
+	}
+}
+
+func TestClosedSince(t *testing.T) {
+	since := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	recent, old := since.AddDate(0, 0, 1), since.AddDate(-2, 0, 0)
+
+	closed := closedSince([]*BacklogIssue{
+		{Number: 1, ClosedAt: &recent, UpdatedAt: recent},
+		{Number: 2, ClosedAt: &old, UpdatedAt: recent},
+		{Number: 3},
+	}, since)
+
+	if len(closed) != 1 || closed[0].Number != 1 {
+		t.Errorf("Expected only the recently closed issue, got %d issues", len(closed))
+	}
+}
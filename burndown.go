@@ -0,0 +1,379 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// Burndown contains the daily progress of a milestone or an iteration. It contains the remaining
+// as well as the completed work, so it can be charted as burndown or burnup
+type Burndown struct {
+	Start  time.Time        `json:"start"`
+	End    time.Time        `json:"end"`
+	Points []*BurndownPoint `json:"points"`
+}
+
+// BurndownPoint is the state of a milestone or an iteration at the end of a day
+type BurndownPoint struct {
+	Date            time.Time `json:"date"`
+	TotalIssues     int       `json:"totalIssues"`
+	OpenIssues      int       `json:"openIssues"`
+	ClosedIssues    int       `json:"closedIssues"`
+	TotalPoints     float64   `json:"totalPoints"`
+	RemainingPoints float64   `json:"remainingPoints"`
+	CompletedPoints float64   `json:"completedPoints"`
+}
+
+// BurndownSnapshot is the recorded state of a milestone or an iteration at the end of a day. In
+// contrast to the state reconstructed from the issues, it also reflects re-opened issues and
+// issues that were removed in the meantime
+type BurndownSnapshot struct {
+	WorkspaceID int64 `gorm:"primary_key;auto_increment:false"`
+	// Scope identifies the milestone or iteration, see milestoneScope and iterationScope
+	Scope string    `gorm:"primary_key"`
+	Date  time.Time `gorm:"primary_key"`
+
+	OpenIssues      int
+	ClosedIssues    int
+	RemainingPoints float64
+	CompletedPoints float64
+}
+
+func milestoneScope(title string) string {
+	return fmt.Sprintf("milestone:%s", title)
+}
+
+func iterationScope(iterationID int64) string {
+	return fmt.Sprintf("iteration:%d", iterationID)
+}
+
+// day truncates the time to the beginning of its day in UTC
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ComputeBurndown computes the daily state from start to end, but not beyond now. The state of
+// days without a snapshot is reconstructed from the creation and closing dates of the issues
+func ComputeBurndown(issues []*BacklogIssue, snapshots []*BurndownSnapshot, start time.Time, end time.Time, now time.Time) *Burndown {
+	burndown := &Burndown{Start: day(start), End: day(end), Points: []*BurndownPoint{}}
+
+	recorded := make(map[time.Time]*BurndownSnapshot)
+	for _, snapshot := range snapshots {
+		recorded[day(snapshot.Date)] = snapshot
+	}
+
+	last := burndown.End
+	if day(now).Before(last) {
+		last = day(now)
+	}
+
+	for d := burndown.Start; !d.After(last); d = d.AddDate(0, 0, 1) {
+		var point *BurndownPoint
+
+		if snapshot, ok := recorded[d]; ok {
+			point = &BurndownPoint{
+				OpenIssues:      snapshot.OpenIssues,
+				ClosedIssues:    snapshot.ClosedIssues,
+				RemainingPoints: snapshot.RemainingPoints,
+				CompletedPoints: snapshot.CompletedPoints,
+			}
+		} else {
+			point = reconstructBurndownPoint(issues, d.AddDate(0, 0, 1))
+		}
+
+		point.Date = d
+		point.TotalIssues = point.OpenIssues + point.ClosedIssues
+		point.TotalPoints = point.RemainingPoints + point.CompletedPoints
+
+		burndown.Points = append(burndown.Points, point)
+	}
+
+	return burndown
+}
+
+// reconstructBurndownPoint computes the state of the issues just before the specified time
+func reconstructBurndownPoint(issues []*BacklogIssue, before time.Time) *BurndownPoint {
+	point := &BurndownPoint{}
+
+	for _, issue := range issues {
+		if !issue.CreatedAt.Before(before) {
+			continue
+		}
+
+		var points float64
+		if issue.Estimate != nil {
+			points = *issue.Estimate
+		}
+
+		if issue.ClosedAt != nil && issue.ClosedAt.Before(before) {
+			point.ClosedIssues++
+			point.CompletedPoints += points
+		} else {
+			point.OpenIssues++
+			point.RemainingPoints += points
+		}
+	}
+
+	return point
+}
+
+// queryMilestoneIssuesAsInstallation retrieves the issues of the workspace milestones with the
+// specified titles using the installation clients of each repository. The milestones of each
+// repository are only listed once to look up their numbers
+func (app *Application) queryMilestoneIssuesAsInstallation(workspace *Workspace, titles []string) (issues map[string][]*BacklogIssue, err error) {
+	var (
+		clients    *GitHubClients
+		repo       *github.Repository
+		milestones []*github.Milestone
+		result     []*BacklogIssue
+	)
+
+	issues = make(map[string][]*BacklogIssue)
+
+	for _, repositoryID := range workspace.RepositoryIDs {
+		if clients, err = app.GetRepositoryInstallationClients(repositoryID); err != nil {
+			return nil, err
+		}
+
+		if repo, _, err = clients.V3.Repositories.GetByID(context.Background(), repositoryID); err != nil {
+			return nil, fmt.Errorf("Could not retrieve repository %d: %w", repositoryID, err)
+		}
+
+		if milestones, err = listMilestones(clients, repo.GetOwner().GetLogin(), repo.GetName()); err != nil {
+			return nil, err
+		}
+
+		numbers := make(map[string]int)
+		for _, milestone := range milestones {
+			numbers[milestone.GetTitle()] = milestone.GetNumber()
+		}
+
+		for _, title := range titles {
+			number, ok := numbers[title]
+			if !ok {
+				continue
+			}
+
+			if result, err = app.queryIssues(clients, repo, githubv4.IssueFilters{
+				Milestone: githubv4.NewString(githubv4.String(strconv.Itoa(number))),
+			}); err != nil {
+				return nil, err
+			}
+
+			if err = app.annotateIssues(workspace.ID, result); err != nil {
+				return nil, err
+			}
+
+			issues[title] = append(issues[title], result...)
+		}
+	}
+
+	return issues, nil
+}
+
+// GetMilestoneBurndown returns the daily progress of the workspace milestone. It starts with the
+// creation of its first issue or the specified time, if not zero, and ends on the due date
+func (app *Application) GetMilestoneBurndown(clients *GitHubClients, workspace *Workspace, milestone *WorkspaceMilestone, from time.Time) (burndown *Burndown, err error) {
+	var (
+		issues    []*BacklogIssue
+		snapshots []*BurndownSnapshot
+	)
+
+	if issues, err = app.QueryMilestoneIssues(clients, workspace, milestone.Title, githubv4.IssueFilters{}); err != nil {
+		return nil, err
+	}
+
+	if snapshots, err = app.db.GetBurndownSnapshots("workspace_id = ? AND scope = ?", workspace.ID, milestoneScope(milestone.Title)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if from.IsZero() {
+		from = now
+		for _, issue := range issues {
+			if issue.CreatedAt.Before(from) {
+				from = issue.CreatedAt
+			}
+		}
+	}
+
+	end := now
+	if milestone.DueOn != nil {
+		end = *milestone.DueOn
+	}
+
+	return ComputeBurndown(issues, snapshots, from, end, now), nil
+}
+
+// GetIterationBurndown returns the daily progress of the iteration
+func (app *Application) GetIterationBurndown(clients *GitHubClients, iteration *Iteration) (burndown *Burndown, err error) {
+	var (
+		report    *IterationReport
+		snapshots []*BurndownSnapshot
+	)
+
+	if report, err = app.GetIterationReport(clients, iteration); err != nil {
+		return nil, err
+	}
+
+	if snapshots, err = app.db.GetBurndownSnapshots("workspace_id = ? AND scope = ?", iteration.WorkspaceID, iterationScope(iteration.ID)); err != nil {
+		return nil, err
+	}
+
+	return ComputeBurndown(report.Issues, snapshots, iteration.Start, iteration.End, time.Now()), nil
+}
+
+// RecordBurndownSnapshots records the current state of all open milestones and all running
+// iterations of all workspaces. Since there is no user, the installation clients are used
+func (app *Application) RecordBurndownSnapshots() (err error) {
+	var workspaces []*Workspace
+
+	if workspaces, err = app.db.GetWorkspaces(nil); err != nil {
+		return fmt.Errorf("Could not fetch workspaces from database: %w", err)
+	}
+
+	for _, workspace := range workspaces {
+		if err = app.recordWorkspaceSnapshots(workspace); err != nil {
+			log.Errorf("Could not record burndown snapshots of workspace %d: %s", workspace.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (app *Application) recordWorkspaceSnapshots(workspace *Workspace) (err error) {
+	var (
+		milestones  []*WorkspaceMilestone
+		iterations  []*Iteration
+		issues      []*BacklogIssue
+		assignments []*IterationIssue
+	)
+
+	now := time.Now()
+
+	if milestones, err = app.db.GetWorkspaceMilestones("workspace_id = ? AND state = ?", workspace.ID, StateOpen); err != nil {
+		return err
+	}
+
+	if len(milestones) > 0 {
+		var (
+			titles  []string
+			byTitle map[string][]*BacklogIssue
+		)
+
+		for _, milestone := range milestones {
+			titles = append(titles, milestone.Title)
+		}
+
+		if byTitle, err = app.queryMilestoneIssuesAsInstallation(workspace, titles); err != nil {
+			return err
+		}
+
+		for _, milestone := range milestones {
+			if err = app.recordSnapshot(workspace.ID, milestoneScope(milestone.Title), byTitle[milestone.Title], now); err != nil {
+				return err
+			}
+		}
+	}
+
+	if iterations, err = app.db.GetIterations("workspace_id = ? AND start <= ? AND \"end\" >= ?", workspace.ID, now, day(now)); err != nil {
+		return err
+	}
+
+	for _, iteration := range iterations {
+		if assignments, err = app.db.GetIterationIssues("iteration_id = ?", iteration.ID); err != nil {
+			return err
+		}
+
+		ids := make(map[int64][]string)
+		for _, assignment := range assignments {
+			ids[assignment.RepositoryID] = append(ids[assignment.RepositoryID], assignment.IssueID)
+		}
+
+		if issues, err = app.getIssuesByIDAsInstallation(ids); err != nil {
+			return err
+		}
+
+		if err = app.recordSnapshot(workspace.ID, iterationScope(iteration.ID), issues, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordSnapshot records the current state of the issues. In contrast to the reconstruction,
+// re-opened issues are counted as open
+func (app *Application) recordSnapshot(workspaceID int64, scope string, issues []*BacklogIssue, now time.Time) (err error) {
+	point := &BurndownPoint{}
+
+	for _, issue := range issues {
+		var points float64
+		if issue.Estimate != nil {
+			points = *issue.Estimate
+		}
+
+		if issue.State == StateClosed {
+			point.ClosedIssues++
+			point.CompletedPoints += points
+		} else {
+			point.OpenIssues++
+			point.RemainingPoints += points
+		}
+	}
+
+	_, err = app.db.Update(&BurndownSnapshot{
+		WorkspaceID:     workspaceID,
+		Scope:           scope,
+		Date:            day(now),
+		OpenIssues:      point.OpenIssues,
+		ClosedIssues:    point.ClosedIssues,
+		RemainingPoints: point.RemainingPoints,
+		CompletedPoints: point.CompletedPoints,
+	})
+
+	return
+}
+
+// getIssuesByIDAsInstallation retrieves the issues with the specified IDs, grouped by their
+// repository, using the installation clients of their repositories
+func (app *Application) getIssuesByIDAsInstallation(ids map[int64][]string) (issues []*BacklogIssue, err error) {
+	var (
+		clients *GitHubClients
+		result  []*BacklogIssue
+	)
+
+	for repositoryID, repositoryIssues := range ids {
+		if clients, err = app.GetRepositoryInstallationClients(repositoryID); err != nil {
+			return nil, err
+		}
+
+		if result, err = app.GetIssuesByID(clients, repositoryIssues); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, result...)
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,41 @@
+package issues
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeBurndown(t *testing.T) {
+	start := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	closed := start.Add(36 * time.Hour)
+	three, five := 3.0, 5.0
+
+	issues := []*BacklogIssue{
+		{CreatedAt: start.Add(-time.Hour), ClosedAt: &closed, Estimate: &three},
+		{CreatedAt: start.Add(time.Hour), Estimate: &five},
+		{CreatedAt: start.Add(50 * time.Hour)},
+	}
+
+	snapshots := []*BurndownSnapshot{
+		{Date: start.AddDate(0, 0, 3), OpenIssues: 1, ClosedIssues: 1, RemainingPoints: 5, CompletedPoints: 3},
+	}
+
+	burndown := ComputeBurndown(issues, snapshots, start.Add(10*time.Hour), start.AddDate(0, 0, 10), start.AddDate(0, 0, 3).Add(time.Hour))
+
+	if len(burndown.Points) != 4 {
+		t.Fatalf("Expected 4 days, got %d", len(burndown.Points))
+	}
+
+	var expected = []BurndownPoint{
+		{Date: start, TotalIssues: 2, OpenIssues: 2, TotalPoints: 8, RemainingPoints: 8},
+		{Date: start.AddDate(0, 0, 1), TotalIssues: 2, OpenIssues: 1, ClosedIssues: 1, TotalPoints: 8, RemainingPoints: 5, CompletedPoints: 3},
+		{Date: start.AddDate(0, 0, 2), TotalIssues: 3, OpenIssues: 2, ClosedIssues: 1, TotalPoints: 8, RemainingPoints: 5, CompletedPoints: 3},
+		{Date: start.AddDate(0, 0, 3), TotalIssues: 2, OpenIssues: 1, ClosedIssues: 1, TotalPoints: 8, RemainingPoints: 5, CompletedPoints: 3},
+	}
+
+	for i, point := range burndown.Points {
+		if *point != expected[i] {
+			t.Errorf("Unexpected point %+v on day %d", point, i)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// CalendarTokenLength is the length of the random calendar tokens in bytes
+const CalendarTokenLength = 32
+
+// RotateCalendarToken creates a new token for the calendar feed of the workspace. Previous tokens
+// are no longer valid
+func (app *Application) RotateCalendarToken(workspace *Workspace) (token string, err error) {
+	b := make([]byte, CalendarTokenLength)
+
+	if _, err = rand.Read(b); err != nil {
+		return "", fmt.Errorf("Could not generate calendar token: %w", err)
+	}
+
+	workspace.CalendarToken = hex.EncodeToString(b)
+
+	if _, err = app.db.Update(workspace); err != nil {
+		return "", err
+	}
+
+	return workspace.CalendarToken, nil
+}
+
+// RevokeCalendarToken disables the calendar feed of the workspace
+func (app *Application) RevokeCalendarToken(workspace *Workspace) (err error) {
+	workspace.CalendarToken = ""
+
+	_, err = app.db.Update(workspace)
+
+	return
+}
+
+// GetCalendarWorkspace returns the workspace, if the token is its calendar token. Otherwise
+// ErrAccessDenied is returned, regardless of whether the workspace exists
+func (app *Application) GetCalendarWorkspace(workspaceID int64, token string) (workspace *Workspace, err error) {
+	if workspace, err = app.GetWorkspace(workspaceID); err != nil {
+		return nil, err
+	}
+
+	if workspace == nil || workspace.CalendarToken == "" || subtle.ConstantTimeCompare([]byte(workspace.CalendarToken), []byte(token)) != 1 {
+		return nil, fmt.Errorf("%w: invalid calendar token", ErrAccessDenied)
+	}
+
+	return workspace, nil
+}
+
+// GetCalendarEvents returns the due dates of the milestones, the iterations and the open issues
+// with a value for a date field of the workspace as calendar events. Since the calendar is not
+// requested by a user, issues are retrieved using the installation clients
+func (app *Application) GetCalendarEvents(workspace *Workspace) (events []*CalendarEvent, err error) {
+	var (
+		milestones []*WorkspaceMilestone
+		iterations []*Iteration
+		fields     []*CustomField
+		values     []*CustomFieldValue
+	)
+
+	events = []*CalendarEvent{}
+
+	if milestones, err = app.GetWorkspaceMilestones(workspace.ID); err != nil {
+		return nil, err
+	}
+
+	for _, milestone := range milestones {
+		if milestone.DueOn == nil {
+			continue
+		}
+
+		events = append(events, newAllDayEvent(fmt.Sprintf("milestone-%d", milestone.ID), fmt.Sprintf("Milestone %s due", milestone.Title), *milestone.DueOn, *milestone.DueOn))
+	}
+
+	if iterations, err = app.GetIterations(workspace.ID); err != nil {
+		return nil, err
+	}
+
+	for _, iteration := range iterations {
+		events = append(events, newAllDayEvent(fmt.Sprintf("iteration-%d", iteration.ID), fmt.Sprintf("Iteration %s", iteration.Name), iteration.Start, iteration.End))
+	}
+
+	if fields, err = app.db.GetCustomFields("workspace_id = ? AND type = ?", workspace.ID, FieldTypeDate); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		if values, err = app.db.GetCustomFieldValues("field_id = ?", field.ID); err != nil {
+			return nil, err
+		}
+
+		var fieldEvents []*CalendarEvent
+		if fieldEvents, err = app.fieldCalendarEvents(field, values); err != nil {
+			return nil, err
+		}
+
+		events = append(events, fieldEvents...)
+	}
+
+	return events, nil
+}
+
+// fieldCalendarEvents creates an event for each open issue with a value of the date field
+func (app *Application) fieldCalendarEvents(field *CustomField, values []*CustomFieldValue) (events []*CalendarEvent, err error) {
+	var issues []*BacklogIssue
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	ids := make(map[int64][]string)
+	dates := make(map[string]time.Time)
+
+	for _, value := range values {
+		date, err := time.Parse(FieldDateFormat, value.Value)
+		if err != nil {
+			continue
+		}
+
+		dates[value.IssueID] = date
+		ids[value.RepositoryID] = append(ids[value.RepositoryID], value.IssueID)
+	}
+
+	if issues, err = app.getIssuesByIDAsInstallation(ids); err != nil {
+		return nil, err
+	}
+
+	for _, issue := range issues {
+		date, ok := dates[issue.ID]
+		if !ok || issue.State == StateClosed {
+			continue
+		}
+
+		event := newAllDayEvent(fmt.Sprintf("field-%d-%s", field.ID, issue.ID), fmt.Sprintf("%s: %s (%s#%d)", field.Name, issue.Title, issue.Repository, issue.Number), date, date)
+		event.URL = issue.URL
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
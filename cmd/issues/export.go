@@ -0,0 +1,122 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"issues"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	WorkspaceFlag          = "workspace"
+	OverwriteEstimatesFlag = "overwrite-estimates"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports a workspace as JSON to stdout",
+	Long:  "Exports the settings, relationships, rankings, estimates and custom fields of a workspace in a versioned JSON format, which can be restored using import.",
+	Args:  cobra.NoArgs,
+	RunE:  doExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Imports a workspace from a JSON export",
+	Long:  "Imports a workspace created by export as a new workspace. The export is read from the file or from stdin, if no file is specified. Existing estimates of the issues are kept, unless they should be overwritten.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  doImport,
+}
+
+func init() {
+	exportCmd.Flags().Int64(WorkspaceFlag, 0, "The ID of the workspace to export")
+	exportCmd.MarkFlagRequired(WorkspaceFlag)
+
+	importCmd.Flags().Bool(OverwriteEstimatesFlag, false, "Overwrite existing estimates of the issues with the exported ones")
+
+	cmd.AddCommand(exportCmd)
+	cmd.AddCommand(importCmd)
+}
+
+// newDatabaseApplication creates an application, which only accesses the database
+func newDatabaseApplication() *issues.Application {
+	db := issues.NewMappedPostgreSQL(viper.GetString(PostgresFlag))
+
+	return issues.NewApplication(viper.GetInt64(GitHubAppIDFlag), db)
+}
+
+func doExport(cmd *cobra.Command, args []string) (err error) {
+	var (
+		workspaceID int64
+		export      *issues.WorkspaceExport
+	)
+
+	if workspaceID, err = cmd.Flags().GetInt64(WorkspaceFlag); err != nil {
+		return err
+	}
+
+	if export, err = newDatabaseApplication().ExportWorkspace(workspaceID); err != nil {
+		return fmt.Errorf("Could not export workspace %d: %w", workspaceID, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(export)
+}
+
+func doImport(cmd *cobra.Command, args []string) (err error) {
+	var (
+		r         io.Reader = os.Stdin
+		export    *issues.WorkspaceExport
+		workspace *issues.Workspace
+		overwrite bool
+	)
+
+	if overwrite, err = cmd.Flags().GetBool(OverwriteEstimatesFlag); err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		var f *os.File
+
+		if f, err = os.Open(args[0]); err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		r = f
+	}
+
+	if export, err = issues.ReadWorkspaceExport(r); err != nil {
+		return err
+	}
+
+	if workspace, err = newDatabaseApplication().ImportWorkspace(export, overwrite); err != nil {
+		return fmt.Errorf("Could not import workspace: %w", err)
+	}
+
+	log.Infof("Imported workspace %s with ID %d", workspace.Name, workspace.ID)
+
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/oxisto/go-httputil"
@@ -35,6 +36,8 @@ const (
 	GitHubAppIDFlag           = "github.app.id"
 	GitHubAppClientIDFlag     = "github.app.clientID"
 	GitHubAppClientSecretFlag = "github.app.clientSecret"
+	DeleteMergedBranchesFlag  = "github.deleteMergedBranches"
+	MirrorEstimateLabelsFlag  = "github.mirrorEstimateLabels"
 
 	DefaultPostgres = "localhost"
 	DefaultListen   = ":8000"
@@ -54,18 +57,22 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	cmd.Flags().String(ListenFlag, DefaultListen, "Host and port to listen to")
-	cmd.Flags().String(PostgresFlag, DefaultPostgres, "Connection string for PostgreSQL")
+	cmd.PersistentFlags().String(PostgresFlag, DefaultPostgres, "Connection string for PostgreSQL")
 	cmd.Flags().String(JwtSecretFlag, DefaultEmpty, "The secret used for signing API tokens")
 	cmd.Flags().String(GitHubAppIDFlag, DefaultEmpty, "The GitHub App ID")
 	cmd.Flags().String(GitHubAppClientIDFlag, DefaultEmpty, "The GitHub App Client ID")
 	cmd.Flags().String(GitHubAppClientSecretFlag, DefaultEmpty, "The GitHub App ID Client Secret")
+	cmd.Flags().Bool(DeleteMergedBranchesFlag, false, "Delete the branch of a pull request once it is merged")
+	cmd.Flags().Bool(MirrorEstimateLabelsFlag, false, "Mirror estimates of issues to points labels")
 
 	viper.BindPFlag(ListenFlag, cmd.Flags().Lookup(ListenFlag))
-	viper.BindPFlag(PostgresFlag, cmd.Flags().Lookup(PostgresFlag))
+	viper.BindPFlag(PostgresFlag, cmd.PersistentFlags().Lookup(PostgresFlag))
 	viper.BindPFlag(JwtSecretFlag, cmd.Flags().Lookup(JwtSecretFlag))
 	viper.BindPFlag(GitHubAppIDFlag, cmd.Flags().Lookup(GitHubAppIDFlag))
 	viper.BindPFlag(GitHubAppClientIDFlag, cmd.Flags().Lookup(GitHubAppClientIDFlag))
 	viper.BindPFlag(GitHubAppClientSecretFlag, cmd.Flags().Lookup(GitHubAppClientSecretFlag))
+	viper.BindPFlag(DeleteMergedBranchesFlag, cmd.Flags().Lookup(DeleteMergedBranchesFlag))
+	viper.BindPFlag(MirrorEstimateLabelsFlag, cmd.Flags().Lookup(MirrorEstimateLabelsFlag))
 }
 
 func initConfig() {
@@ -94,6 +101,11 @@ func doCmd(cmd *cobra.Command, args []string) {
 
 	app := issues.NewApplication(appID, db)
 	app.AddServiceConnection(issues.ServiceGitHub, viper.GetString(GitHubAppClientIDFlag), viper.GetString(GitHubAppClientSecretFlag))
+	app.DeleteMergedBranches = viper.GetBool(DeleteMergedBranchesFlag)
+	app.MirrorEstimateLabels = viper.GetBool(MirrorEstimateLabelsFlag)
+
+	app.Schedule("burndown snapshots", 24*time.Hour, app.RecordBurndownSnapshots)
+	app.Schedule("stale issues", 24*time.Hour, app.MarkStaleIssues)
 
 	router := handlers.LoggingHandler(&httputil.LogWriter{Level: log.DebugLevel, Component: "http"}, routes.NewRouter(app, viper.GetString(JwtSecretFlag)))
 
@@ -0,0 +1,284 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCSVColumns are the columns of an export, if neither the request nor the view specify any
+var DefaultCSVColumns = []string{"repository", "number", "title", "state", "labels", "assignees", "milestone", "estimate"}
+
+// BoardCSVColumn is the name of the column containing the board column of an issue in an export of a board
+const BoardCSVColumn = "column"
+
+// tableFlushRows is the number of rows after which an export is flushed to the underlying writer,
+// so that clients receive the first rows without waiting for the whole export to be encoded
+const tableFlushRows = 100
+
+// TableWriter writes the rows of an export in the format of a spreadsheet
+type TableWriter interface {
+	// WriteRow writes a row of cells
+	WriteRow(values []string) error
+
+	// Flush writes all buffered rows to the underlying writer
+	Flush() error
+
+	// Close flushes all rows and completes the document
+	Close() error
+}
+
+// csvTable writes rows as CSV
+type csvTable struct {
+	writer *csv.Writer
+}
+
+// NewCSVTable returns a table writer, which writes CSV. Values, which would be interpreted as
+// formulas, are escaped
+func NewCSVTable(w io.Writer) TableWriter {
+	return &csvTable{writer: csv.NewWriter(w)}
+}
+
+// WriteRow writes the escaped values as row
+func (t *csvTable) WriteRow(values []string) error {
+	row := make([]string, len(values))
+	for i, value := range values {
+		row[i] = EscapeCSVCell(value)
+	}
+
+	return t.writer.Write(row)
+}
+
+// Flush writes all buffered rows to the underlying writer
+func (t *csvTable) Flush() error {
+	t.writer.Flush()
+
+	return t.writer.Error()
+}
+
+// Close flushes all rows, CSV needs no completion
+func (t *csvTable) Close() error {
+	return t.Flush()
+}
+
+// csvFormulaPrefixes are the characters, which make spreadsheet applications interpret a cell as a
+// formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// EscapeCSVCell prefixes values, which would be interpreted as formulas by spreadsheet
+// applications, with a single quote. Titles, labels and field values can be chosen by anyone
+// opening an issue, so they must not be executed when opening an export
+func EscapeCSVCell(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], csvFormulaPrefixes) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// ParseBacklogColumns parses a comma-separated list of backlog columns
+func ParseBacklogColumns(s string) (columns []string, err error) {
+	for _, column := range strings.Split(s, ",") {
+		column = strings.TrimSpace(column)
+
+		if column == "" {
+			continue
+		}
+
+		if !isBacklogColumn(column) {
+			return nil, fmt.Errorf("%w: unknown column %s", ErrValidationFailed, column)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return
+}
+
+// ValidateBacklogColumns checks, whether all custom fields referenced by the columns are custom
+// fields of the workspace
+func (app *Application) ValidateBacklogColumns(workspaceID int64, columns []string) (err error) {
+	var fields []*CustomField
+
+	for _, column := range columns {
+		if !strings.HasPrefix(column, FieldColumnPrefix) {
+			continue
+		}
+
+		if fields == nil {
+			if fields, err = app.GetCustomFields(workspaceID); err != nil {
+				return err
+			}
+		}
+
+		var found bool
+		for _, field := range fields {
+			found = found || strings.EqualFold(field.Name, strings.TrimPrefix(column, FieldColumnPrefix))
+		}
+
+		if !found {
+			return fmt.Errorf("%w: unknown column %s", ErrValidationFailed, column)
+		}
+	}
+
+	return nil
+}
+
+// BacklogColumnValue returns the value of the column of the issue as it is displayed in an export
+func BacklogColumnValue(issue *BacklogIssue, column string) string {
+	if strings.HasPrefix(column, FieldColumnPrefix) {
+		name := strings.TrimPrefix(column, FieldColumnPrefix)
+
+		for field, value := range issue.Fields {
+			if strings.EqualFold(field, name) {
+				return value
+			}
+		}
+
+		return ""
+	}
+
+	switch column {
+	case "repository":
+		return issue.Repository
+	case "number":
+		return strconv.Itoa(issue.Number)
+	case "title":
+		return issue.Title
+	case "state":
+		return issue.State
+	case "labels":
+		return strings.Join(issue.Labels, ", ")
+	case "assignees":
+		return strings.Join(issue.Assignees, ", ")
+	case "milestone":
+		return issue.Milestone
+	case "reactions":
+		return strconv.Itoa(issue.Reactions)
+	case "createdAt":
+		return issue.CreatedAt.Format(time.RFC3339)
+	case "updatedAt":
+		return issue.UpdatedAt.Format(time.RFC3339)
+	case "rank":
+		return issue.Rank
+	case "estimate":
+		if issue.Estimate == nil {
+			return ""
+		}
+
+		return strconv.FormatFloat(*issue.Estimate, 'f', -1, 64)
+	case "url":
+		return issue.URL
+	}
+
+	return ""
+}
+
+// WriteBacklog writes the issues to the table with the specified columns. Rows are flushed
+// regularly, so that the export itself is not buffered as a whole
+func WriteBacklog(table TableWriter, issues []*BacklogIssue, columns []string) (err error) {
+	return writeBacklog(table, columns, func() (issue *BacklogIssue, err error) {
+		if len(issues) > 0 {
+			issue, issues = issues[0], issues[1:]
+		}
+
+		return issue, nil
+	})
+}
+
+// WriteBacklogStream writes the issues of the stream to the table with the specified columns.
+// Neither the issues nor the export are held in memory as a whole
+func WriteBacklogStream(table TableWriter, stream *BacklogStream, columns []string) (err error) {
+	return writeBacklog(table, columns, stream.Next)
+}
+
+// writeBacklog writes the issues returned by next to the table, until it returns nil
+func writeBacklog(table TableWriter, columns []string, next func() (*BacklogIssue, error)) (err error) {
+	var issue *BacklogIssue
+
+	writer := &issueTable{TableWriter: table, columns: columns}
+
+	if err = writer.WriteRow(columns); err != nil {
+		return err
+	}
+
+	for {
+		if issue, err = next(); err != nil {
+			return err
+		}
+
+		if issue == nil {
+			break
+		}
+
+		if err = writer.write(issue); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// WriteBoard writes the issues of the board to the table. The first column contains the name of
+// the board column of each issue
+func WriteBoard(table TableWriter, board *Board, columns []string) (err error) {
+	writer := &issueTable{TableWriter: table, columns: columns}
+
+	if err = writer.WriteRow(append([]string{BoardCSVColumn}, columns...)); err != nil {
+		return err
+	}
+
+	for _, column := range board.Columns {
+		for _, issue := range column.Issues {
+			if err = writer.write(issue, column.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+// issueTable writes issues as rows of a table and flushes them regularly
+type issueTable struct {
+	TableWriter
+	columns []string
+	rows    int
+}
+
+// write writes the row of the issue, prefixed with additional values
+func (t *issueTable) write(issue *BacklogIssue, prefix ...string) (err error) {
+	row := prefix
+	for _, column := range t.columns {
+		row = append(row, BacklogColumnValue(issue, column))
+	}
+
+	if err = t.WriteRow(row); err != nil {
+		return err
+	}
+
+	t.rows++
+
+	if t.rows%tableFlushRows == 0 {
+		return t.Flush()
+	}
+
+	return nil
+}
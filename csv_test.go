@@ -0,0 +1,67 @@
+package issues
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteBacklogCSV(t *testing.T) {
+	five := 5.0
+
+	issues := []*BacklogIssue{
+		{Repository: "aybaze/api", Number: 1, Title: "Crash, on start", Labels: []string{"bug", "ui"}, Estimate: &five, Fields: map[string]string{"Severity": "high"}},
+		{Repository: "aybaze/frontend", Number: 2, Title: "Say \"hello\"", Labels: []string{}},
+	}
+
+	columns, err := ParseBacklogColumns("repository, number,title,labels,estimate,field:severity")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteBacklog(NewCSVTable(&buf), issues, columns); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := "repository,number,title,labels,estimate,field:severity\n" +
+		"aybaze/api,1,\"Crash, on start\",\"bug, ui\",5,high\n" +
+		"aybaze/frontend,2,\"Say \"\"hello\"\"\",,,\n"
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	board := &Board{Columns: []*BoardColumnIssues{{BoardColumn: &BoardColumn{Name: "Doing"}, Issues: issues[:1]}}}
+
+	if err = WriteBoard(NewCSVTable(&buf), board, []string{"number"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if buf.String() != "column,number\nDoing,1\n" {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+
+	for _, invalid := range []string{"title,owner", "field:"} {
+		if _, err = ParseBacklogColumns(invalid); err == nil {
+			t.Errorf("Expected error for columns %s", invalid)
+		}
+	}
+}
+
+func TestEscapeCSVCell(t *testing.T) {
+	tests := map[string]string{
+		"=HYPERLINK(\"http://example.com\")": "'=HYPERLINK(\"http://example.com\")",
+		"+1":                                 "'+1",
+		"-1+2":                               "'-1+2",
+		"@SUM(A1)":                           "'@SUM(A1)",
+		"Crash on start":                     "Crash on start",
+		"":                                   "",
+	}
+
+	for value, expected := range tests {
+		if escaped := EscapeCSVCell(value); escaped != expected {
+			t.Errorf("Expected %q to be escaped as %q, got %q", value, expected, escaped)
+		}
+	}
+}
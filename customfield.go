@@ -0,0 +1,377 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/lib/pq"
+)
+
+// Types of custom fields
+const (
+	FieldTypeText   = "text"
+	FieldTypeNumber = "number"
+	FieldTypeEnum   = "enum"
+	FieldTypeDate   = "date"
+)
+
+// FieldDateFormat is the format of the values of date fields
+const FieldDateFormat = "2006-01-02"
+
+// reservedFieldNames cannot be used as field names, since they are qualifiers of backlog queries
+var reservedFieldNames = []string{"label", "assignee", "repo", "sort"}
+
+// CustomField is a workspace-defined field, which can be set on the issues of the workspace
+type CustomField struct {
+	ID          int64  `json:"id"`
+	WorkspaceID int64  `json:"workspaceID"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	// Options contains the allowed values of an enum field
+	Options pq.StringArray `json:"options" gorm:"type:text[]"`
+}
+
+// CustomFieldValue is the value of a custom field of an issue
+type CustomFieldValue struct {
+	FieldID      int64  `json:"fieldID" gorm:"primary_key;auto_increment:false"`
+	IssueID      string `json:"issueID" gorm:"primary_key"`
+	RepositoryID int64  `json:"repositoryID"`
+	Number       int    `json:"number"`
+	Value        string `json:"value"`
+}
+
+// Validate checks, whether the field can be stored
+func (f *CustomField) Validate() error {
+	f.Name = strings.TrimSpace(f.Name)
+
+	if f.Name == "" {
+		return fmt.Errorf("%w: field name must not be empty", ErrValidationFailed)
+	}
+
+	if strings.ContainsAny(f.Name, " \t\n:=\"") {
+		return fmt.Errorf("%w: field name %s must not contain spaces, quotes, ':' or '='", ErrValidationFailed, f.Name)
+	}
+
+	if strings.HasPrefix(f.Name, "-") || containsFold(reservedFieldNames, f.Name) {
+		return fmt.Errorf("%w: %s cannot be used as field name", ErrValidationFailed, f.Name)
+	}
+
+	switch f.Type {
+	case FieldTypeText, FieldTypeNumber, FieldTypeDate:
+		f.Options = nil
+	case FieldTypeEnum:
+		if len(f.Options) == 0 {
+			return fmt.Errorf("%w: enum field %s needs at least one option", ErrValidationFailed, f.Name)
+		}
+	default:
+		return fmt.Errorf("%w: unknown field type %s", ErrValidationFailed, f.Type)
+	}
+
+	return nil
+}
+
+// NormalizeValue checks, whether the value is valid for the field and returns it in its canonical
+// form, e.g. an enum option in the spelling of the field definition
+func (f *CustomField) NormalizeValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch f.Type {
+	case FieldTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s is not a number", ErrValidationFailed, value)
+		}
+
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case FieldTypeEnum:
+		for _, option := range f.Options {
+			if strings.EqualFold(option, value) {
+				return option, nil
+			}
+		}
+
+		return "", fmt.Errorf("%w: %s must be one of %s", ErrValidationFailed, f.Name, strings.Join(f.Options, ", "))
+	case FieldTypeDate:
+		if _, err := time.Parse(FieldDateFormat, value); err != nil {
+			return "", fmt.Errorf("%w: %s is not a date of the form YYYY-MM-DD", ErrValidationFailed, value)
+		}
+	}
+
+	return value, nil
+}
+
+// GetCustomFields returns all custom fields of the workspace
+func (app *Application) GetCustomFields(workspaceID int64) ([]*CustomField, error) {
+	return app.db.GetCustomFields("workspace_id = ?", workspaceID)
+}
+
+// GetCustomField returns the custom field of the workspace or nil, if it does not exist
+func (app *Application) GetCustomField(workspaceID int64, fieldID int64) (*CustomField, error) {
+	fields, err := app.db.GetCustomFields("workspace_id = ? AND id = ?", workspaceID, fieldID)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+
+	return fields[0], nil
+}
+
+// findCustomField returns the custom field of the workspace with the specified name, ignoring
+// the case, or nil if it does not exist
+func (app *Application) findCustomField(workspaceID int64, name string) (*CustomField, error) {
+	fields, err := app.db.GetCustomFields("workspace_id = ? AND LOWER(name) = LOWER(?)", workspaceID, name)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+
+	return fields[0], nil
+}
+
+// CreateCustomField validates and stores a new custom field of the workspace
+func (app *Application) CreateCustomField(workspaceID int64, field *CustomField) (err error) {
+	if err = app.validateCustomField(workspaceID, field); err != nil {
+		return err
+	}
+
+	field.ID = 0
+	field.WorkspaceID = workspaceID
+
+	return app.db.Insert(field)
+}
+
+// UpdateCustomField validates and stores the modified custom field. Changing the type of a field
+// which already has values is not allowed
+func (app *Application) UpdateCustomField(field *CustomField, previous *CustomField) (err error) {
+	var values []*CustomFieldValue
+
+	if err = app.validateCustomField(field.WorkspaceID, field); err != nil {
+		return err
+	}
+
+	if field.Type != previous.Type {
+		if values, err = app.db.GetCustomFieldValues("field_id = ?", field.ID); err != nil {
+			return err
+		}
+
+		if len(values) > 0 {
+			return fmt.Errorf("%w: type of field %s cannot be changed, since issues already have values", ErrValidationFailed, field.Name)
+		}
+	}
+
+	_, err = app.db.Update(field)
+
+	return
+}
+
+// DeleteCustomField deletes the custom field and all its values
+func (app *Application) DeleteCustomField(field *CustomField) (err error) {
+	if err = app.db.Delete(&CustomFieldValue{}, "field_id = ?", field.ID); err != nil {
+		return err
+	}
+
+	return app.db.Delete(field)
+}
+
+func (app *Application) validateCustomField(workspaceID int64, field *CustomField) (err error) {
+	var existing *CustomField
+
+	if err = field.Validate(); err != nil {
+		return err
+	}
+
+	if existing, err = app.findCustomField(workspaceID, field.Name); err != nil {
+		return err
+	}
+
+	if existing != nil && existing.ID != field.ID {
+		return fmt.Errorf("%w: field %s already exists", ErrValidationFailed, field.Name)
+	}
+
+	return nil
+}
+
+// SetCustomFieldValue sets the value of the field for the issue. An empty value removes it
+func (app *Application) SetCustomFieldValue(field *CustomField, repo *github.Repository, issue *github.Issue, value string) (err error) {
+	if strings.TrimSpace(value) == "" {
+		return app.db.Delete(&CustomFieldValue{}, "field_id = ? AND issue_id = ?", field.ID, issue.GetNodeID())
+	}
+
+	if value, err = field.NormalizeValue(value); err != nil {
+		return err
+	}
+
+	if _, err = app.db.Update(&CustomFieldValue{
+		FieldID:      field.ID,
+		IssueID:      issue.GetNodeID(),
+		RepositoryID: repo.GetID(),
+		Number:       issue.GetNumber(),
+		Value:        value,
+	}); err != nil {
+		return fmt.Errorf("Could not store field %s of %s: %w", field.Name, GetIssueIdentifier(repo, issue), err)
+	}
+
+	log.Infof("Set field %s of issue %s to %s", field.Name, GetIssueIdentifier(repo, issue), value)
+
+	return nil
+}
+
+// SetCustomFieldValueByReference sets the value of the field for the issue identified by its
+// repository and number. The issue is retrieved using the clients of the user
+func (app *Application) SetCustomFieldValueByReference(clients *GitHubClients, workspace *Workspace, field *CustomField, repository string, number int, value string) (err error) {
+	var (
+		repo  *github.Repository
+		issue *github.Issue
+	)
+
+	if repo, issue, err = app.getWorkspaceIssue(clients, workspace, repository, number); err != nil {
+		return err
+	}
+
+	return app.SetCustomFieldValue(field, repo, issue, value)
+}
+
+// SetCustomFieldByName sets the value of the field with the specified name in all workspaces
+// containing the repository of the issue. This is used by the /set command, which comments on the
+// issue if the field does not exist or the value is invalid
+func (app *Application) SetCustomFieldByName(clients *GitHubClients, repo *github.Repository, issue *github.Issue, name string, value string) (err error) {
+	var (
+		workspaces []*Workspace
+		field      *CustomField
+		found      bool
+		body       string
+	)
+
+	if workspaces, err = app.db.GetWorkspaces("? = ANY(repository_ids)", repo.GetID()); err != nil {
+		return fmt.Errorf("Could not fetch workspaces from database: %w", err)
+	}
+
+	for _, workspace := range workspaces {
+		if field, err = app.findCustomField(workspace.ID, name); err != nil {
+			return err
+		}
+
+		if field == nil {
+			continue
+		}
+
+		found = true
+
+		if err = app.SetCustomFieldValue(field, repo, issue, value); err != nil {
+			if !errors.Is(err, ErrValidationFailed) {
+				return err
+			}
+
+			body = fmt.Sprintf("Could not set field **%s**: %s", field.Name, strings.TrimPrefix(err.Error(), ErrValidationFailed.Error()+": "))
+			break
+		}
+	}
+
+	if !found {
+		body = fmt.Sprintf("Field **%s** does not exist in any workspace of this repository.", name)
+	}
+
+	if body == "" {
+		return nil
+	}
+
+	if _, _, err = clients.V3.Issues.CreateComment(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueComment{
+		Body: &body,
+	}); err != nil {
+		return fmt.Errorf("Creating comment for issue %s failed: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	return nil
+}
+
+// ValidateBacklogQuery checks, whether all fields referenced in the query are custom fields
+// of the workspace
+func (app *Application) ValidateBacklogQuery(workspaceID int64, q *BacklogQuery) (err error) {
+	var fields []*CustomField
+
+	if len(q.Fields) == 0 && len(q.ExcludedFields) == 0 {
+		return nil
+	}
+
+	if fields, err = app.GetCustomFields(workspaceID); err != nil {
+		return err
+	}
+
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+
+	for _, filters := range []map[string][]string{q.Fields, q.ExcludedFields} {
+		for name := range filters {
+			if !containsFold(names, name) {
+				return fmt.Errorf("%w: unknown qualifier %s", ErrValidationFailed, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setCustomFieldValues sets the values of the custom fields of the workspace on the issues
+func (app *Application) setCustomFieldValues(workspaceID int64, issues []*BacklogIssue) (err error) {
+	var (
+		fields []*CustomField
+		values []*CustomFieldValue
+		ids    []string
+	)
+
+	if fields, err = app.GetCustomFields(workspaceID); err != nil {
+		return err
+	}
+
+	if len(fields) == 0 || len(issues) == 0 {
+		return nil
+	}
+
+	names := make(map[int64]string)
+	var fieldIDs []int64
+	for _, field := range fields {
+		names[field.ID] = field.Name
+		fieldIDs = append(fieldIDs, field.ID)
+	}
+
+	byID := make(map[string]*BacklogIssue)
+	for _, issue := range issues {
+		byID[issue.ID] = issue
+		ids = append(ids, issue.ID)
+	}
+
+	if values, err = app.db.GetCustomFieldValues("field_id IN (?) AND issue_id IN (?)", fieldIDs, ids); err != nil {
+		return fmt.Errorf("Could not fetch field values from database: %w", err)
+	}
+
+	for _, value := range values {
+		issue := byID[value.IssueID]
+
+		if issue.Fields == nil {
+			issue.Fields = make(map[string]string)
+		}
+
+		issue.Fields[names[value.FieldID]] = value.Value
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package issues
+
+import "testing"
+
+func TestCustomFieldNormalizeValue(t *testing.T) {
+	var tests = []struct {
+		field    CustomField
+		value    string
+		expected string
+		valid    bool
+	}{
+		{CustomField{Name: "customer", Type: FieldTypeText}, " ACME ", "ACME", true},
+		{CustomField{Name: "effort", Type: FieldTypeNumber}, "2.50", "2.5", true},
+		{CustomField{Name: "effort", Type: FieldTypeNumber}, "two", "", false},
+		{CustomField{Name: "severity", Type: FieldTypeEnum, Options: []string{"Low", "High"}}, "high", "High", true},
+		{CustomField{Name: "severity", Type: FieldTypeEnum, Options: []string{"Low", "High"}}, "medium", "", false},
+		{CustomField{Name: "target", Type: FieldTypeDate}, "2020-03-01", "2020-03-01", true},
+		{CustomField{Name: "target", Type: FieldTypeDate}, "01.03.2020", "", false},
+	}
+
+	for _, test := range tests {
+		value, err := test.field.NormalizeValue(test.value)
+
+		if (err == nil) != test.valid || value != test.expected {
+			t.Errorf("Unexpected value %q (%v) for %q of field %s", value, err, test.value, test.field.Name)
+		}
+	}
+}
+
+func TestCustomFieldValidate(t *testing.T) {
+	for _, field := range []CustomField{
+		{Name: "", Type: FieldTypeText},
+		{Name: "target date", Type: FieldTypeDate},
+		{Name: "label", Type: FieldTypeText},
+		{Name: "severity", Type: FieldTypeEnum},
+		{Name: "customer", Type: "list"},
+	} {
+		if err := field.Validate(); err == nil {
+			t.Errorf("Expected field %+v to be invalid", field)
+		}
+	}
+}
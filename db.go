@@ -26,10 +26,25 @@ type Database interface {
 	Init()
 	Insert(object interface{}) (err error)
 	Update(object interface{}) (rowsChanged int64, err error)
+	Delete(object interface{}, where ...interface{}) (err error)
+	Transaction(fc func(tx Database) error) (err error)
 	GetServiceToken(service string, userID int64) (*ServiceToken, error)
 	GetWorkspace(workspaceID int64) (*Workspace, error)
 	GetWorkspaces(query interface{}, args ...interface{}) ([]*Workspace, error)
 	GetRelationships(query interface{}, args ...interface{}) ([]*Relationship, error)
+	GetWorkspaceMembers(query interface{}, args ...interface{}) ([]*WorkspaceMember, error)
+	GetBacklogRanks(query interface{}, args ...interface{}) ([]*BacklogRank, error)
+	GetViews(query interface{}, args ...interface{}) ([]*View, error)
+	GetBoardColumns(query interface{}, args ...interface{}) ([]*BoardColumn, error)
+	GetWorkspaceMilestones(query interface{}, args ...interface{}) ([]*WorkspaceMilestone, error)
+	GetWorkspaceLabels(query interface{}, args ...interface{}) ([]*WorkspaceLabel, error)
+	GetIterations(query interface{}, args ...interface{}) ([]*Iteration, error)
+	GetIterationIssues(query interface{}, args ...interface{}) ([]*IterationIssue, error)
+	GetEstimates(query interface{}, args ...interface{}) ([]*Estimate, error)
+	GetCustomFields(query interface{}, args ...interface{}) ([]*CustomField, error)
+	GetCustomFieldValues(query interface{}, args ...interface{}) ([]*CustomFieldValue, error)
+	GetBurndownSnapshots(query interface{}, args ...interface{}) ([]*BurndownSnapshot, error)
+	GetStaleSettings(query interface{}, args ...interface{}) ([]*StaleSettings, error)
 }
 
 type MappedPostgreSQL struct {
@@ -55,6 +70,19 @@ func (p *MappedPostgreSQL) Init() {
 	p.db.AutoMigrate(&Workspace{})
 	p.db.AutoMigrate(&ServiceToken{})
 	p.db.AutoMigrate(&Relationship{})
+	p.db.AutoMigrate(&WorkspaceMember{})
+	p.db.AutoMigrate(&BacklogRank{})
+	p.db.AutoMigrate(&View{})
+	p.db.AutoMigrate(&BoardColumn{})
+	p.db.AutoMigrate(&WorkspaceMilestone{})
+	p.db.AutoMigrate(&WorkspaceLabel{})
+	p.db.AutoMigrate(&Iteration{})
+	p.db.AutoMigrate(&IterationIssue{})
+	p.db.AutoMigrate(&Estimate{})
+	p.db.AutoMigrate(&CustomField{})
+	p.db.AutoMigrate(&CustomFieldValue{})
+	p.db.AutoMigrate(&BurndownSnapshot{})
+	p.db.AutoMigrate(&StaleSettings{})
 
 	log.Infof("Using PostgreSQL @ %s", p.host)
 }
@@ -75,6 +103,13 @@ func (p *MappedPostgreSQL) Update(object interface{}) (rowsChanged int64, err er
 	return
 }
 
+// Delete deletes the object from our database. If no conditions are supplied, the object is identified by its primary key.
+func (p *MappedPostgreSQL) Delete(object interface{}, where ...interface{}) (err error) {
+	log.Debugf("Deleting %+v", object)
+
+	return p.db.Delete(object, where...).Error
+}
+
 func (p *MappedPostgreSQL) Where(holder interface{}, query string, args ...interface{}) error {
 	return p.db.Where(query, args).Find(&holder).Error
 }
@@ -100,7 +135,7 @@ func (p *MappedPostgreSQL) GetWorkspaces(query interface{}, args ...interface{})
 	db = p.db
 
 	if query != nil {
-		db = db.Where(query, args)
+		db = db.Where(query, args...)
 	}
 
 	err = db.Find(&w).Error
@@ -112,6 +147,14 @@ func (p *MappedPostgreSQL) GetWorkspaces(query interface{}, args ...interface{})
 	return w, err
 }
 
+// Transaction runs fc within a transaction. All changes done using tx are rolled back, if fc
+// returns an error
+func (p *MappedPostgreSQL) Transaction(fc func(tx Database) error) (err error) {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		return fc(&MappedPostgreSQL{host: p.host, db: tx})
+	})
+}
+
 func (p *MappedPostgreSQL) GetServiceToken(service string, userID int64) (*ServiceToken, error) {
 	t := ServiceToken{
 		Service: "GitHub",
@@ -139,7 +182,7 @@ func (p *MappedPostgreSQL) GetRelationships(query interface{}, args ...interface
 	db = p.db
 
 	if query != nil {
-		db = db.Where(query, args)
+		db = db.Where(query, args...)
 	}
 
 	err = db.Find(&r).Error
@@ -150,3 +193,150 @@ func (p *MappedPostgreSQL) GetRelationships(query interface{}, args ...interface
 
 	return r, err
 }
+
+func (p *MappedPostgreSQL) GetWorkspaceMembers(query interface{}, args ...interface{}) ([]*WorkspaceMember, error) {
+	var m []*WorkspaceMember
+
+	if err := p.find(&m, query, args...); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (p *MappedPostgreSQL) GetBacklogRanks(query interface{}, args ...interface{}) ([]*BacklogRank, error) {
+	var r []*BacklogRank
+
+	if err := p.find(&r, query, args...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (p *MappedPostgreSQL) GetViews(query interface{}, args ...interface{}) ([]*View, error) {
+	var v []*View
+
+	if err := p.find(&v, query, args...); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (p *MappedPostgreSQL) GetBoardColumns(query interface{}, args ...interface{}) ([]*BoardColumn, error) {
+	var c []*BoardColumn
+
+	if err := p.find(&c, query, args...); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (p *MappedPostgreSQL) GetWorkspaceMilestones(query interface{}, args ...interface{}) ([]*WorkspaceMilestone, error) {
+	var m []*WorkspaceMilestone
+
+	if err := p.find(&m, query, args...); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (p *MappedPostgreSQL) GetWorkspaceLabels(query interface{}, args ...interface{}) ([]*WorkspaceLabel, error) {
+	var l []*WorkspaceLabel
+
+	if err := p.find(&l, query, args...); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (p *MappedPostgreSQL) GetIterations(query interface{}, args ...interface{}) ([]*Iteration, error) {
+	var i []*Iteration
+
+	if err := p.find(&i, query, args...); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (p *MappedPostgreSQL) GetIterationIssues(query interface{}, args ...interface{}) ([]*IterationIssue, error) {
+	var i []*IterationIssue
+
+	if err := p.find(&i, query, args...); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (p *MappedPostgreSQL) GetEstimates(query interface{}, args ...interface{}) ([]*Estimate, error) {
+	var e []*Estimate
+
+	if err := p.find(&e, query, args...); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (p *MappedPostgreSQL) GetCustomFields(query interface{}, args ...interface{}) ([]*CustomField, error) {
+	var f []*CustomField
+
+	if err := p.find(&f, query, args...); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (p *MappedPostgreSQL) GetCustomFieldValues(query interface{}, args ...interface{}) ([]*CustomFieldValue, error) {
+	var v []*CustomFieldValue
+
+	if err := p.find(&v, query, args...); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (p *MappedPostgreSQL) GetBurndownSnapshots(query interface{}, args ...interface{}) ([]*BurndownSnapshot, error) {
+	var s []*BurndownSnapshot
+
+	if err := p.find(&s, query, args...); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// find retrieves all objects matching the query into holder, which needs to be a pointer to a slice
+func (p *MappedPostgreSQL) find(holder interface{}, query interface{}, args ...interface{}) error {
+	db := p.db
+
+	if query != nil {
+		db = db.Where(query, args...)
+	}
+
+	err := db.Find(holder).Error
+
+	if gorm.IsRecordNotFoundError(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (p *MappedPostgreSQL) GetStaleSettings(query interface{}, args ...interface{}) ([]*StaleSettings, error) {
+	var s []*StaleSettings
+
+	if err := p.find(&s, query, args...); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
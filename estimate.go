@@ -0,0 +1,238 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// PointsLabelPrefix is the prefix of the labels, which mirror the estimate of an issue
+const PointsLabelPrefix = "points:"
+
+// Estimate is the estimated effort of an issue in story points
+type Estimate struct {
+	IssueID      string  `json:"issueID" gorm:"primary_key"`
+	RepositoryID int64   `json:"repositoryID"`
+	Number       int     `json:"number"`
+	Points       float64 `json:"points"`
+}
+
+// EstimateSummary contains the sum of the estimates of a group of issues, such as a milestone or an epic
+type EstimateSummary struct {
+	Issues          int     `json:"issues"`
+	EstimatedIssues int     `json:"estimatedIssues"`
+	Points          float64 `json:"points"`
+}
+
+// ParsePoints parses the argument of the /estimate command
+func ParsePoints(s string) (points float64, err error) {
+	if points, err = strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+		return 0, fmt.Errorf("%w: %s is not a valid estimate", ErrValidationFailed, s)
+	}
+
+	if err = validatePoints(points); err != nil {
+		return 0, err
+	}
+
+	return points, nil
+}
+
+// validatePoints checks, whether the points can be stored as estimate. NaN and infinity are
+// accepted by ParseFloat, but cannot be encoded as JSON
+func validatePoints(points float64) error {
+	if math.IsNaN(points) || math.IsInf(points, 0) {
+		return fmt.Errorf("%w: estimate must be a finite number", ErrValidationFailed)
+	}
+
+	if points < 0 {
+		return fmt.Errorf("%w: estimate must not be negative", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+// PointsLabel returns the label, which mirrors the specified estimate
+func PointsLabel(points float64) string {
+	return PointsLabelPrefix + strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+// SumEstimates sums up the estimates of the issues. Issues without an estimate count as zero points
+func SumEstimates(issues []*BacklogIssue) *EstimateSummary {
+	summary := &EstimateSummary{Issues: len(issues)}
+
+	for _, issue := range issues {
+		if issue.Estimate == nil {
+			continue
+		}
+
+		summary.EstimatedIssues++
+		summary.Points += *issue.Estimate
+	}
+
+	return summary
+}
+
+// SetEstimate stores the estimate of the issue. If configured, the estimate is mirrored to a
+// points label on the issue as well
+func (app *Application) SetEstimate(clients *GitHubClients, repo *github.Repository, issue *github.Issue, points float64) (err error) {
+	if err = validatePoints(points); err != nil {
+		return err
+	}
+
+	if _, err = app.db.Update(&Estimate{
+		IssueID:      issue.GetNodeID(),
+		RepositoryID: repo.GetID(),
+		Number:       issue.GetNumber(),
+		Points:       points,
+	}); err != nil {
+		return fmt.Errorf("Could not store estimate of %s: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	log.Infof("Estimated issue %s with %v points", GetIssueIdentifier(repo, issue), points)
+
+	if !app.MirrorEstimateLabels {
+		return nil
+	}
+
+	return app.setPointsLabel(clients, repo, issue, PointsLabel(points))
+}
+
+// SetEstimateByReference stores the estimate of the issue identified by its repository and
+// number. The issue is retrieved using the clients of the user
+func (app *Application) SetEstimateByReference(clients *GitHubClients, workspace *Workspace, repository string, number int, points float64) (err error) {
+	var (
+		repo  *github.Repository
+		issue *github.Issue
+	)
+
+	if repo, issue, err = app.getWorkspaceIssue(clients, workspace, repository, number); err != nil {
+		return err
+	}
+
+	return app.SetEstimate(clients, repo, issue, points)
+}
+
+// GetMilestoneEstimate sums up the estimates of all issues in the milestone with the specified
+// title across all repositories of the workspace
+func (app *Application) GetMilestoneEstimate(clients *GitHubClients, workspace *Workspace, title string) (summary *EstimateSummary, err error) {
+	var issues []*BacklogIssue
+
+	if issues, err = app.QueryMilestoneIssues(clients, workspace, title, githubv4.IssueFilters{}); err != nil {
+		return nil, err
+	}
+
+	return SumEstimates(issues), nil
+}
+
+// GetEpicEstimate sums up the estimates of all issues listed as tasks in the epic
+func (app *Application) GetEpicEstimate(clients *GitHubClients, workspace *Workspace, repository string, number int) (summary *EstimateSummary, err error) {
+	var (
+		epic      *github.Issue
+		repo      *github.Repository
+		estimates []*Estimate
+	)
+
+	if repo, epic, err = app.getWorkspaceIssue(clients, workspace, repository, number); err != nil {
+		return nil, err
+	}
+
+	numbers := EpicItemNumbers(epic.GetBody())
+	summary = &EstimateSummary{Issues: len(numbers)}
+
+	if len(numbers) == 0 {
+		return summary, nil
+	}
+
+	if estimates, err = app.db.GetEstimates("repository_id = ? AND number IN (?)", repo.GetID(), numbers); err != nil {
+		return nil, err
+	}
+
+	for _, estimate := range estimates {
+		summary.EstimatedIssues++
+		summary.Points += estimate.Points
+	}
+
+	return summary, nil
+}
+
+// estimateIssues sets the estimate of all issues, which have one
+func (app *Application) estimateIssues(issues []*BacklogIssue) (err error) {
+	var (
+		estimates []*Estimate
+		ids       []string
+	)
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+
+	if estimates, err = app.db.GetEstimates("issue_id IN (?)", ids); err != nil {
+		return fmt.Errorf("Could not fetch estimates from database: %w", err)
+	}
+
+	points := make(map[string]float64)
+	for _, estimate := range estimates {
+		points[estimate.IssueID] = estimate.Points
+	}
+
+	for _, issue := range issues {
+		if p, ok := points[issue.ID]; ok {
+			issue.Estimate = &p
+		}
+	}
+
+	return nil
+}
+
+// setPointsLabel makes sure, that the issue only has the specified points label
+func (app *Application) setPointsLabel(clients *GitHubClients, repo *github.Repository, issue *github.Issue, label string) (err error) {
+	var found bool
+
+	for _, l := range issue.Labels {
+		if l.GetName() == label {
+			found = true
+			continue
+		}
+
+		if !strings.HasPrefix(l.GetName(), PointsLabelPrefix) {
+			continue
+		}
+
+		if _, err = clients.V3.Issues.RemoveLabelForIssue(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), l.GetName()); err != nil {
+			return fmt.Errorf("Could not remove label %s from %s: %w", l.GetName(), GetIssueIdentifier(repo, issue), err)
+		}
+	}
+
+	if found {
+		return nil
+	}
+
+	if _, _, err = clients.V3.Issues.AddLabelsToIssue(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), []string{label}); err != nil {
+		return fmt.Errorf("Could not add label %s to %s: %w", label, GetIssueIdentifier(repo, issue), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package issues
+
+import "testing"
+
+func TestParsePoints(t *testing.T) {
+	if points, err := ParsePoints(" 2.5 "); err != nil || points != 2.5 {
+		t.Errorf("Expected 2.5 points, got %v (%v)", points, err)
+	}
+
+	for _, s := range []string{"", "five", "-1", "NaN", "Inf", "-Inf", "infinity"} {
+		if _, err := ParsePoints(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+
+	if label := PointsLabel(5); label != "points:5" {
+		t.Errorf("Unexpected points label %s", label)
+	}
+}
+
+func TestSumEstimates(t *testing.T) {
+	three, five := 3.0, 5.0
+
+	summary := SumEstimates([]*BacklogIssue{{Estimate: &three}, {}, {Estimate: &five}})
+
+	if summary.Issues != 3 || summary.EstimatedIssues != 2 || summary.Points != 8 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+}
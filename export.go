@@ -0,0 +1,344 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportVersion is the version of the export format. It needs to be increased on incompatible changes
+const ExportVersion = 1
+
+// WorkspaceExport contains everything stored about a workspace. IDs are the ones of the exporting
+// database and are re-assigned on import. Relationships and estimates belong to the repositories
+// of the workspace
+type WorkspaceExport struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+
+	Workspace         *Workspace            `json:"workspace"`
+	Members           []*WorkspaceMember    `json:"members"`
+	Ranks             []*BacklogRank        `json:"ranks"`
+	Views             []*View               `json:"views"`
+	BoardColumns      []*BoardColumn        `json:"boardColumns"`
+	Relationships     []*Relationship       `json:"relationships"`
+	Milestones        []*WorkspaceMilestone `json:"milestones"`
+	Labels            []*WorkspaceLabel     `json:"labels"`
+	Iterations        []*Iteration          `json:"iterations"`
+	IterationIssues   []*IterationIssue     `json:"iterationIssues"`
+	Estimates         []*Estimate           `json:"estimates"`
+	CustomFields      []*CustomField        `json:"customFields"`
+	CustomFieldValues []*CustomFieldValue   `json:"customFieldValues"`
+	StaleSettings     *StaleSettings        `json:"staleSettings,omitempty"`
+}
+
+// ReadWorkspaceExport reads an export and checks, whether its version is supported
+func ReadWorkspaceExport(r io.Reader) (export *WorkspaceExport, err error) {
+	export = &WorkspaceExport{}
+
+	if err = json.NewDecoder(r).Decode(export); err != nil {
+		return nil, fmt.Errorf("%w: could not decode export: %s", ErrValidationFailed, err)
+	}
+
+	if export.Version < 1 || export.Version > ExportVersion {
+		return nil, fmt.Errorf("%w: unsupported export version %d", ErrValidationFailed, export.Version)
+	}
+
+	if export.Workspace == nil {
+		return nil, fmt.Errorf("%w: export does not contain a workspace", ErrValidationFailed)
+	}
+
+	return export, nil
+}
+
+// ExportWorkspace collects everything stored about the workspace
+func (app *Application) ExportWorkspace(workspaceID int64) (export *WorkspaceExport, err error) {
+	export = &WorkspaceExport{Version: ExportVersion, ExportedAt: time.Now()}
+
+	if export.Workspace, err = app.GetWorkspace(workspaceID); err != nil {
+		return nil, err
+	}
+
+	if export.Workspace == nil {
+		return nil, fmt.Errorf("%w: workspace %d does not exist", ErrValidationFailed, workspaceID)
+	}
+
+	if export.Members, err = app.db.GetWorkspaceMembers("workspace_id = ?", workspaceID); err != nil {
+		return nil, err
+	}
+
+	if export.Ranks, err = app.db.GetBacklogRanks("workspace_id = ?", workspaceID); err != nil {
+		return nil, err
+	}
+
+	if export.Views, err = app.db.GetViews("workspace_id = ?", workspaceID); err != nil {
+		return nil, err
+	}
+
+	if export.BoardColumns, err = app.GetBoardColumns(workspaceID); err != nil {
+		return nil, err
+	}
+
+	if export.Milestones, err = app.GetWorkspaceMilestones(workspaceID); err != nil {
+		return nil, err
+	}
+
+	if export.Labels, err = app.GetWorkspaceLabels(workspaceID); err != nil {
+		return nil, err
+	}
+
+	if export.Iterations, err = app.GetIterations(workspaceID); err != nil {
+		return nil, err
+	}
+
+	for _, iteration := range export.Iterations {
+		var assignments []*IterationIssue
+
+		if assignments, err = app.db.GetIterationIssues("iteration_id = ?", iteration.ID); err != nil {
+			return nil, err
+		}
+
+		export.IterationIssues = append(export.IterationIssues, assignments...)
+	}
+
+	if len(export.Workspace.RepositoryIDs) > 0 {
+		if export.Relationships, err = app.db.GetRelationships("repository_id IN (?)", []int64(export.Workspace.RepositoryIDs)); err != nil {
+			return nil, err
+		}
+
+		if export.Estimates, err = app.db.GetEstimates("repository_id IN (?)", []int64(export.Workspace.RepositoryIDs)); err != nil {
+			return nil, err
+		}
+	}
+
+	if export.CustomFields, err = app.GetCustomFields(workspaceID); err != nil {
+		return nil, err
+	}
+
+	for _, field := range export.CustomFields {
+		var values []*CustomFieldValue
+
+		if values, err = app.db.GetCustomFieldValues("field_id = ?", field.ID); err != nil {
+			return nil, err
+		}
+
+		export.CustomFieldValues = append(export.CustomFieldValues, values...)
+	}
+
+	var settings []*StaleSettings
+	if settings, err = app.db.GetStaleSettings("workspace_id = ?", workspaceID); err != nil {
+		return nil, err
+	}
+
+	if len(settings) > 0 {
+		export.StaleSettings = settings[0]
+	}
+
+	return export, nil
+}
+
+// ImportWorkspace stores the exported workspace as a new workspace. All IDs assigned by the
+// database are re-assigned and references to them are updated accordingly. Relationships are not
+// owned by the workspace, so existing ones are kept. Estimates are not owned by it either, so
+// existing ones are only overwritten if requested. Stale issue detection is imported as disabled
+func (app *Application) ImportWorkspace(export *WorkspaceExport, overwriteEstimates bool) (workspace *Workspace, err error) {
+	// a failed import must not leave a partial workspace behind
+	if err = app.db.Transaction(func(tx Database) (err error) {
+		workspace, err = importWorkspace(tx, export, overwriteEstimates)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Imported workspace %s as workspace %d", workspace.Name, workspace.ID)
+
+	return workspace, nil
+}
+
+// importWorkspace stores the contents of the export using the database, which is the transaction
+// of ImportWorkspace
+func importWorkspace(db Database, export *WorkspaceExport, overwriteEstimates bool) (workspace *Workspace, err error) {
+	workspace = export.Workspace
+	workspace.ID = 0
+
+	if workspace.RepositoryIDs == nil {
+		workspace.RepositoryIDs = RepositoryRefArray{}
+	}
+
+	if err = db.Insert(workspace); err != nil {
+		return nil, err
+	}
+
+	for _, member := range export.Members {
+		member.WorkspaceID = workspace.ID
+
+		if err = db.Insert(member); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rank := range export.Ranks {
+		rank.WorkspaceID = workspace.ID
+
+		if err = db.Insert(rank); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, label := range export.Labels {
+		label.WorkspaceID = workspace.ID
+
+		if err = db.Insert(label); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, view := range export.Views {
+		view.ID, view.WorkspaceID = 0, workspace.ID
+
+		if err = db.Insert(view); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, column := range export.BoardColumns {
+		column.ID, column.WorkspaceID = 0, workspace.ID
+
+		if err = db.Insert(column); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, milestone := range export.Milestones {
+		milestone.ID, milestone.WorkspaceID = 0, workspace.ID
+
+		if err = db.Insert(milestone); err != nil {
+			return nil, err
+		}
+	}
+
+	iterations := make(map[int64]int64)
+	for _, iteration := range export.Iterations {
+		previous := iteration.ID
+		iteration.ID, iteration.WorkspaceID = 0, workspace.ID
+
+		if err = db.Insert(iteration); err != nil {
+			return nil, err
+		}
+
+		iterations[previous] = iteration.ID
+	}
+
+	for _, assignment := range export.IterationIssues {
+		var ok bool
+
+		if assignment.IterationID, ok = iterations[assignment.IterationID]; !ok {
+			return nil, fmt.Errorf("%w: issue %s is assigned to an unknown iteration", ErrValidationFailed, assignment.IssueID)
+		}
+
+		if err = db.Insert(assignment); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := make(map[int64]int64)
+	for _, field := range export.CustomFields {
+		previous := field.ID
+		field.ID, field.WorkspaceID = 0, workspace.ID
+
+		if err = db.Insert(field); err != nil {
+			return nil, err
+		}
+
+		fields[previous] = field.ID
+	}
+
+	for _, value := range export.CustomFieldValues {
+		var ok bool
+
+		if value.FieldID, ok = fields[value.FieldID]; !ok {
+			return nil, fmt.Errorf("%w: issue %s has a value of an unknown field", ErrValidationFailed, value.IssueID)
+		}
+
+		if err = db.Insert(value); err != nil {
+			return nil, err
+		}
+	}
+
+	// relationships are not owned by the workspace, so existing ones are kept
+	for _, relationship := range export.Relationships {
+		var existing []*Relationship
+
+		if !workspace.RepositoryIDs.Contains(relationship.RepositoryID) {
+			return nil, fmt.Errorf("%w: relationship of issue %d belongs to repository %d, which is not part of the workspace", ErrValidationFailed, relationship.IssueID, relationship.RepositoryID)
+		}
+
+		if existing, err = db.GetRelationships("repository_id = ? AND issue_id = ? AND other_issue_id = ?", relationship.RepositoryID, relationship.IssueID, relationship.OtherIssueID); err != nil {
+			return nil, err
+		}
+
+		if len(existing) > 0 {
+			continue
+		}
+
+		if err = db.Insert(relationship); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, estimate := range export.Estimates {
+		var existing []*Estimate
+
+		if !workspace.RepositoryIDs.Contains(estimate.RepositoryID) {
+			return nil, fmt.Errorf("%w: estimate of issue %s belongs to repository %d, which is not part of the workspace", ErrValidationFailed, estimate.IssueID, estimate.RepositoryID)
+		}
+
+		if overwriteEstimates {
+			if _, err = db.Update(estimate); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if existing, err = db.GetEstimates("issue_id = ?", estimate.IssueID); err != nil {
+			return nil, err
+		}
+
+		if len(existing) > 0 {
+			continue
+		}
+
+		if err = db.Insert(estimate); err != nil {
+			return nil, err
+		}
+	}
+
+	// stale issue detection needs to be enabled by a user with push access to all repositories,
+	// see UpdateStaleSettings, which cannot be checked on import
+	if export.StaleSettings != nil {
+		export.StaleSettings.WorkspaceID = workspace.ID
+		export.StaleSettings.Enabled = false
+
+		if err = db.Insert(export.StaleSettings); err != nil {
+			return nil, err
+		}
+	}
+
+	return workspace, nil
+}
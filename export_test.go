@@ -0,0 +1,23 @@
+package issues
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadWorkspaceExport(t *testing.T) {
+	export, err := ReadWorkspaceExport(strings.NewReader(`{"version": 1, "workspace": {"id": 3, "name": "aybaze", "repositoryIDs": [1, 2]}, "iterations": [{"id": 5, "name": "Sprint 1"}]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if export.Workspace.Name != "aybaze" || len(export.Workspace.RepositoryIDs) != 2 || export.Iterations[0].ID != 5 {
+		t.Errorf("Unexpected export %+v", export)
+	}
+
+	for _, invalid := range []string{`{"version": 2, "workspace": {}}`, `{"workspace": {}}`, `{"version": 1}`, `[]`} {
+		if _, err = ReadWorkspaceExport(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected error for export %s", invalid)
+		}
+	}
+}
@@ -11,6 +11,7 @@ require (
 	github.com/gregjones/httpcache v0.0.0-20190611155906-901d90724c79
 	github.com/jinzhu/gorm v1.9.16
 	github.com/jsternberg/markdownfmt v0.0.0-20180204232022-c2a5702991e3
+	github.com/lib/pq v1.1.1
 	github.com/mattn/go-runewidth v0.0.8 // indirect
 	github.com/oxisto/go-httputil v0.3.3
 	github.com/shurcooL/githubv4 v0.0.0-20200802174311-f27d2ca7f6d5
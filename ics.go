@@ -0,0 +1,116 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsMaxLineLength is the maximum length of a line of an iCalendar in octets, see RFC 5545
+const icsMaxLineLength = 75
+
+// CalendarEvent is an all-day event of an iCalendar. The end is exclusive
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// WriteCalendar writes the events as iCalendar according to RFC 5545
+func WriteCalendar(w io.Writer, name string, events []*CalendarEvent, now time.Time) error {
+	b := bufio.NewWriter(w)
+
+	line := func(property string, value string) {
+		b.WriteString(foldICSLine(property + ":" + value))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Issues//Workspace Calendar//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", icsEscaper.Replace(name))
+
+	for _, event := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", now.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+		line("DTEND;VALUE=DATE", event.End.Format("20060102"))
+		line("SUMMARY", icsEscaper.Replace(event.Summary))
+
+		if event.Description != "" {
+			line("DESCRIPTION", icsEscaper.Replace(event.Description))
+		}
+
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+
+	return b.Flush()
+}
+
+// foldICSLine splits lines longer than allowed into several lines, each continuation line starting
+// with a space. Multi-byte characters are not split
+func foldICSLine(line string) string {
+	var (
+		b      strings.Builder
+		length int
+	)
+
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+
+		if length+size > icsMaxLineLength {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+
+		b.WriteRune(r)
+		length += size
+	}
+
+	return b.String()
+}
+
+// calendarDate returns the date of the time as all-day date
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// newAllDayEvent creates an event spanning the days from start to end, both inclusive
+func newAllDayEvent(uid string, summary string, start time.Time, end time.Time) *CalendarEvent {
+	return &CalendarEvent{
+		UID:     fmt.Sprintf("%s@issues", uid),
+		Summary: summary,
+		Start:   calendarDate(start),
+		End:     calendarDate(end).AddDate(0, 0, 1),
+	}
+}
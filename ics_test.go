@@ -0,0 +1,62 @@
+package issues
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWriteCalendar(t *testing.T) {
+	var b bytes.Buffer
+
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := newAllDayEvent("milestone-1", "Release 1.0; finally, done", time.Date(2020, 3, 2, 15, 0, 0, 0, time.UTC), time.Date(2020, 3, 2, 15, 0, 0, 0, time.UTC))
+	event.Description = "First line\nSecond line"
+
+	if err := WriteCalendar(&b, "Workspace", []*CalendarEvent{event}, now); err != nil {
+		t.Fatalf("Could not write calendar: %s", err)
+	}
+
+	output := b.String()
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:milestone-1@issues\r\n",
+		"DTSTAMP:20200301T120000Z\r\n",
+		"DTSTART;VALUE=DATE:20200302\r\n",
+		"DTEND;VALUE=DATE:20200303\r\n",
+		"SUMMARY:Release 1.0\\; finally\\, done\r\n",
+		"DESCRIPTION:First line\\nSecond line\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected calendar to contain %q, got %q", expected, output)
+		}
+	}
+
+	if strings.Contains(strings.ReplaceAll(output, "\r\n", ""), "\n") {
+		t.Errorf("Expected all lines to end with CRLF, got %q", output)
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("ä", 50)
+
+	folded := foldICSLine(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > icsMaxLineLength {
+			t.Errorf("Expected line to be at most %d octets, got %d", icsMaxLineLength, len(part))
+		}
+
+		if !utf8.ValidString(part) {
+			t.Errorf("Expected folding not to split characters, got %q", part)
+		}
+	}
+
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("Expected unfolded line to be %q, got %q", line, unfolded)
+	}
+}
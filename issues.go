@@ -40,6 +40,12 @@ type Application struct {
 	AppID int64
 	db    Database
 	gh    *oauth2.Config
+
+	// DeleteMergedBranches specifies, whether branches of merged pull requests are deleted
+	DeleteMergedBranches bool
+
+	// MirrorEstimateLabels specifies, whether estimates are mirrored to points labels on the issue
+	MirrorEstimateLabels bool
 }
 
 func init() {
@@ -47,7 +53,7 @@ func init() {
 }
 
 func NewApplication(appID int64, db Database) *Application {
-	app := Application{appID, db, nil}
+	app := Application{AppID: appID, db: db}
 
 	db.Init()
 
@@ -96,46 +102,73 @@ func (app *Application) RemoveOldContainers(clients *GitHubClients) {
 	fmt.Printf("%v", err)
 }
 
-func (app *Application) UpdateEpicStatus(clients *GitHubClients, event github.IssuesEvent) {
-	issue := event.GetIssue()
-	body := issue.GetBody()
-
-	r, _ := regexp.Compile("^/epic #([0-9]+)")
+var epicCommand = regexp.MustCompile("^/epic #([0-9]+)")
 
+// EpicNumbers returns the numbers of all epics an issue is connected to using the /epic command
+func EpicNumbers(body string) (numbers []int) {
 	// we only want the command at the beginning of the line, so split it
 	for _, line := range strings.Split(body, "\n") {
-		match := r.FindStringSubmatch(line)
+		match := epicCommand.FindStringSubmatch(line)
 
 		if len(match) != 2 {
 			continue
 		}
 
 		i, _ := strconv.ParseInt(match[1], 10, 64)
-		epicNumber := int(i)
+		numbers = append(numbers, int(i))
+	}
+
+	return
+}
+
+func (app *Application) UpdateEpicStatus(clients *GitHubClients, event github.IssuesEvent) {
+	issue := event.GetIssue()
+
+	for _, epicNumber := range EpicNumbers(issue.GetBody()) {
 		epicIssueString := fmt.Sprintf("%s/%s#%d", event.Repo.Owner.GetLogin(), event.Repo.GetName(), epicNumber)
 
 		log.Infof("Issue %s needs to be connected to epic %s", GetIssueIdentifier(event.GetRepo(), event.GetIssue()), epicIssueString)
 
-		// find issue
-		epic, _, _ := clients.V3.Issues.Get(context.Background(), event.Repo.Owner.GetLogin(), event.Repo.GetName(), epicNumber)
+		if err := app.editEpic(clients, event.GetRepo(), epicNumber, func(body string) (string, IssueUpdateStatus) {
+			return CheckIfContainsIssue(body, issue.GetTitle(), issue.GetNumber())
+		}); err != nil {
+			log.Errorf("%s", err)
+			return
+		}
+	}
+}
+
+// editEpic applies the modify function to the body of the epic and updates the epic,
+// if the body was modified
+func (app *Application) editEpic(clients *GitHubClients, repo *github.Repository, epicNumber int, modify func(body string) (string, IssueUpdateStatus)) (err error) {
+	var epic *github.Issue
 
-		epicBody := epic.GetBody()
-		// the parser has problems with \r\n
-		epicBody = strings.ReplaceAll(epicBody, "\r\n", "\n")
-		body, status := CheckIfContainsIssue(epicBody, issue.GetTitle(), issue.GetNumber())
+	epicIssueString := fmt.Sprintf("%s/%s#%d", repo.GetOwner().GetLogin(), repo.GetName(), epicNumber)
 
-		if status != NotModified {
-			request := github.IssueRequest{
-				Body: &body,
-			}
+	// find issue
+	if epic, _, err = clients.V3.Issues.Get(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), epicNumber); err != nil {
+		return fmt.Errorf("Retrieving epic %s failed: %w", epicIssueString, err)
+	}
 
-			// update issue text
-			if _, _, err := clients.V3.Issues.Edit(context.Background(), event.Repo.Owner.GetLogin(), event.Repo.GetName(), epicNumber, &request); err != nil {
-				log.Errorf("Updating issue %s failed: %s", epicIssueString, err)
-				return
-			}
-		}
+	epicBody := epic.GetBody()
+	// the parser has problems with \r\n
+	epicBody = strings.ReplaceAll(epicBody, "\r\n", "\n")
+	body, status := modify(epicBody)
+
+	if status == NotModified {
+		return nil
+	}
+
+	request := github.IssueRequest{
+		Body: &body,
 	}
+
+	// update issue text
+	if _, _, err = clients.V3.Issues.Edit(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), epicNumber, &request); err != nil {
+		return fmt.Errorf("Updating issue %s failed: %w", epicIssueString, err)
+	}
+
+	return nil
 }
 
 func GetIssueIdentifier(repo *github.Repository, issue *github.Issue) string {
@@ -218,9 +251,9 @@ func (o *TaskWalker) Walk(node *blackfriday.Node, entering bool) blackfriday.Wal
 		text := string(node.Literal)
 
 		// inside a task list
-		if strings.HasPrefix(text, "[ ]") {
+		if isTask(text) {
 			// check the text for the issue
-			if strings.Contains(text, fmt.Sprintf("#%d", o.IssueNumber)) {
+			if containsIssueReference(text, o.IssueNumber) {
 				o.status = NotModified
 				o.exists = true
 				// no need to continue
@@ -231,3 +264,150 @@ func (o *TaskWalker) Walk(node *blackfriday.Node, entering bool) blackfriday.Wal
 
 	return blackfriday.GoToNext
 }
+
+// AnnotateEpicItem annotates the task of the issue in the body of an epic with a reference to
+// the pull request, that implements it. If done is set, the task is checked as well
+func AnnotateEpicItem(body string, issueNumber int, pullNumber int, done bool) (string, IssueUpdateStatus) {
+	return walkEpicItem(body, &pullRequestWalker{IssueNumber: issueNumber, PullNumber: pullNumber, Done: done})
+}
+
+// RemoveEpicItemAnnotation removes the reference to the pull request from the task of the issue
+// in the body of an epic. This is used for pull requests, which were closed without being merged
+func RemoveEpicItemAnnotation(body string, issueNumber int, pullNumber int) (string, IssueUpdateStatus) {
+	return walkEpicItem(body, &pullRequestWalker{IssueNumber: issueNumber, PullNumber: pullNumber, Remove: true})
+}
+
+// walkEpicItem modifies the task of the issue in the body of an epic using the walker
+func walkEpicItem(body string, walker *pullRequestWalker) (string, IssueUpdateStatus) {
+	r := markdown.NewRenderer(&markdown.Options{})
+
+	md := blackfriday.New()
+	ast := md.Parse([]byte(body))
+
+	ast.Walk(walker.Walk)
+
+	if walker.status == NotModified {
+		return body, NotModified
+	}
+
+	var buf bytes.Buffer
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return r.RenderNode(&buf, node, entering)
+	})
+
+	return string(buf.Bytes()), walker.status
+}
+
+type pullRequestWalker struct {
+	status      IssueUpdateStatus
+	IssueNumber int
+	PullNumber  int
+	Done        bool
+	// Remove removes the reference to the pull request instead of adding it
+	Remove bool
+}
+
+func (o *pullRequestWalker) Walk(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	if !entering || node.Type != blackfriday.Item {
+		return blackfriday.GoToNext
+	}
+
+	p := node.FirstChild
+	if p == nil || p.Type != blackfriday.Paragraph || p.FirstChild == nil || p.FirstChild.Type != blackfriday.Text {
+		return blackfriday.GoToNext
+	}
+
+	// the text of an item can be split across several nodes, so we need to collect it first
+	var text string
+	for child := p.FirstChild; child != nil; child = child.Next {
+		text += string(child.Literal)
+	}
+
+	if !isTask(text) || !containsIssueReference(text, o.IssueNumber) {
+		return blackfriday.GoToNext
+	}
+
+	if o.Remove {
+		annotation := []byte(fmt.Sprintf(" (PR #%d)", o.PullNumber))
+
+		for child := p.FirstChild; child != nil; child = child.Next {
+			if bytes.Contains(child.Literal, annotation) {
+				child.Literal = bytes.Replace(child.Literal, annotation, nil, -1)
+
+				o.status = UpdatedText
+			}
+		}
+
+		// no need to continue
+		return blackfriday.Terminate
+	}
+
+	if !strings.Contains(text, fmt.Sprintf("PR #%d", o.PullNumber)) {
+		annotation := blackfriday.NewNode(blackfriday.Text)
+		annotation.Literal = []byte(fmt.Sprintf(" (PR #%d)", o.PullNumber))
+		p.AppendChild(annotation)
+
+		o.status = UpdatedText
+	}
+
+	if o.Done && strings.HasPrefix(text, "[ ]") {
+		p.FirstChild.Literal = append([]byte("[x]"), p.FirstChild.Literal[3:]...)
+
+		o.status = UpdatedText
+	}
+
+	// no need to continue
+	return blackfriday.Terminate
+}
+
+// EpicItemNumbers returns the numbers of all issues referenced in the tasks of an epic
+func EpicItemNumbers(body string) (numbers []int) {
+	ast := blackfriday.New().Parse([]byte(body))
+
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.Item {
+			return blackfriday.GoToNext
+		}
+
+		p := node.FirstChild
+		if p == nil || p.Type != blackfriday.Paragraph {
+			return blackfriday.GoToNext
+		}
+
+		var text string
+		for child := p.FirstChild; child != nil; child = child.Next {
+			text += string(child.Literal)
+		}
+
+		if !isTask(text) {
+			return blackfriday.GoToNext
+		}
+
+		// annotations of pull requests are not references to items of the epic
+		for _, match := range issueReference.FindAllStringSubmatch(text, -1) {
+			if match[1] != "" {
+				continue
+			}
+
+			i, _ := strconv.Atoi(match[2])
+			numbers = append(numbers, i)
+			break
+		}
+
+		return blackfriday.GoToNext
+	})
+
+	return
+}
+
+var issueReference = regexp.MustCompile(`(PR )?#([0-9]+)\b`)
+
+// isTask checks, whether the text of a list item is an open or completed task
+func isTask(text string) bool {
+	return strings.HasPrefix(text, "[ ]") || strings.HasPrefix(text, "[x]")
+}
+
+// containsIssueReference checks, whether the text contains a reference to the issue
+func containsIssueReference(text string, number int) bool {
+	return regexp.MustCompile(fmt.Sprintf(`#%d\b`, number)).MatchString(text)
+}
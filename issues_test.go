@@ -1,6 +1,10 @@
 package issues
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestCheckContainsIfIssue(t *testing.T) {
 	body := "This issue serves a collection of several issues related to HUD design\n\n- [ ] Movable windows\n- [ ] Resizable windows (#9)\n- [ ] Persistent window position across game-player (#7)\n- [ ]  Items should be link-able from inventory"
@@ -10,3 +14,46 @@ func TestCheckContainsIfIssue(t *testing.T) {
 	newBody, status := CheckIfContainsIssue(body, "Something really awesome", 8)
 	log.Printf("%s %d", newBody, status)
 }
+
+func TestAnnotateEpicItem(t *testing.T) {
+	body := "Epic\n\n- [ ] Movable windows (#19)\n- [ ] Resizable windows (#9)\n"
+
+	newBody, status := AnnotateEpicItem(body, 9, 12, true)
+	if status != UpdatedText {
+		t.Fatalf("Expected epic to be updated, got status %d", status)
+	}
+
+	if !strings.Contains(newBody, "[x] Resizable windows (#9) (PR #12)") || !strings.Contains(newBody, "[ ] Movable windows (#19)\n") {
+		t.Errorf("Unexpected epic body: %s", newBody)
+	}
+
+	if _, status = AnnotateEpicItem(newBody, 9, 12, true); status != NotModified {
+		t.Errorf("Expected already annotated epic not to be modified, got status %d", status)
+	}
+}
+
+func TestRemoveEpicItemAnnotation(t *testing.T) {
+	body := "Epic\n\n- [ ] Movable windows (#19) (PR #12)\n- [ ] Resizable windows (#9) (PR #12)\n"
+
+	newBody, status := RemoveEpicItemAnnotation(body, 9, 12)
+	if status != UpdatedText {
+		t.Fatalf("Expected epic to be updated, got status %d", status)
+	}
+
+	if !strings.Contains(newBody, "[ ] Resizable windows (#9)\n") || !strings.Contains(newBody, "[ ] Movable windows (#19) (PR #12)\n") {
+		t.Errorf("Unexpected epic body: %s", newBody)
+	}
+
+	if _, status = RemoveEpicItemAnnotation(newBody, 9, 12); status != NotModified {
+		t.Errorf("Expected epic without annotation not to be modified, got status %d", status)
+	}
+}
+
+func TestEpicItemNumbers(t *testing.T) {
+	body := "Epic for #3\n\n- [ ] Movable windows (#19)\n- [x] Resizable windows (#9) (PR #12)\n- Not a task (#7)\n- [ ] PR #4 needs a follow-up in #21\n"
+
+	numbers := EpicItemNumbers(body)
+	if !reflect.DeepEqual(numbers, []int{19, 9, 21}) {
+		t.Errorf("Unexpected epic items: %v", numbers)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Iteration is a time-boxed sprint of a workspace with a capacity in points
+type Iteration struct {
+	ID          int64     `json:"id"`
+	WorkspaceID int64     `json:"workspaceID"`
+	Name        string    `json:"name"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Capacity    float64   `json:"capacity"`
+}
+
+// IterationIssue assigns an issue to an iteration
+type IterationIssue struct {
+	IterationID  int64  `json:"iterationID" gorm:"primary_key;auto_increment:false"`
+	IssueID      string `json:"issueID" gorm:"primary_key"`
+	RepositoryID int64  `json:"repositoryID"`
+	Number       int    `json:"number"`
+}
+
+// IterationReport contains the issues committed to an iteration and the work completed so far.
+// Issues without an estimate count as zero points
+type IterationReport struct {
+	*Iteration
+	Issues            []*BacklogIssue `json:"issues"`
+	CommittedPoints   float64         `json:"committedPoints"`
+	CompletedPoints   float64         `json:"completedPoints"`
+	RemainingCapacity float64         `json:"remainingCapacity"`
+}
+
+// Validate checks, whether the iteration can be stored
+func (i *Iteration) Validate() error {
+	i.Name = strings.TrimSpace(i.Name)
+
+	if i.Name == "" {
+		return fmt.Errorf("%w: iteration name must not be empty", ErrValidationFailed)
+	}
+
+	if !i.End.After(i.Start) {
+		return fmt.Errorf("%w: iteration %s needs to end after it starts", ErrValidationFailed, i.Name)
+	}
+
+	if i.Capacity < 0 {
+		return fmt.Errorf("%w: iteration %s cannot have a negative capacity", ErrValidationFailed, i.Name)
+	}
+
+	return nil
+}
+
+// GetIterations returns all iterations of the workspace
+func (app *Application) GetIterations(workspaceID int64) ([]*Iteration, error) {
+	return app.db.GetIterations("workspace_id = ?", workspaceID)
+}
+
+// GetIteration returns the iteration of the workspace or nil, if it does not exist
+func (app *Application) GetIteration(workspaceID int64, iterationID int64) (*Iteration, error) {
+	iterations, err := app.db.GetIterations("workspace_id = ? AND id = ?", workspaceID, iterationID)
+	if err != nil || len(iterations) == 0 {
+		return nil, err
+	}
+
+	return iterations[0], nil
+}
+
+// CreateIteration validates and stores a new iteration of the workspace
+func (app *Application) CreateIteration(workspaceID int64, iteration *Iteration) (err error) {
+	if err = iteration.Validate(); err != nil {
+		return err
+	}
+
+	iteration.ID = 0
+	iteration.WorkspaceID = workspaceID
+
+	return app.db.Insert(iteration)
+}
+
+// UpdateIteration validates and stores the modified iteration
+func (app *Application) UpdateIteration(iteration *Iteration) (err error) {
+	if err = iteration.Validate(); err != nil {
+		return err
+	}
+
+	_, err = app.db.Update(iteration)
+
+	return
+}
+
+// DeleteIteration deletes the iteration, all its issue assignments and its burndown snapshots
+func (app *Application) DeleteIteration(iteration *Iteration) (err error) {
+	if err = app.db.Delete(&IterationIssue{}, "iteration_id = ?", iteration.ID); err != nil {
+		return err
+	}
+
+	if err = app.db.Delete(&BurndownSnapshot{}, "workspace_id = ? AND scope = ?", iteration.WorkspaceID, iterationScope(iteration.ID)); err != nil {
+		return err
+	}
+
+	return app.db.Delete(iteration)
+}
+
+// AssignIteration assigns the issue to the iteration. An issue can only be part of one iteration
+// per workspace, so it is removed from all other iterations of the workspace
+func (app *Application) AssignIteration(iteration *Iteration, repo *github.Repository, issue *github.Issue) (err error) {
+	var iterations []*Iteration
+
+	if iterations, err = app.GetIterations(iteration.WorkspaceID); err != nil {
+		return err
+	}
+
+	// the issue must not lose its previous iteration, if it cannot be assigned to the new one
+	if err = app.db.Transaction(func(tx Database) (err error) {
+		for _, other := range iterations {
+			if err = tx.Delete(&IterationIssue{}, "iteration_id = ? AND issue_id = ?", other.ID, issue.GetNodeID()); err != nil {
+				return err
+			}
+		}
+
+		return tx.Insert(&IterationIssue{
+			IterationID:  iteration.ID,
+			IssueID:      issue.GetNodeID(),
+			RepositoryID: repo.GetID(),
+			Number:       issue.GetNumber(),
+		})
+	}); err != nil {
+		return err
+	}
+
+	log.Infof("Assigned issue %s to iteration %s", GetIssueIdentifier(repo, issue), iteration.Name)
+
+	return nil
+}
+
+// AssignIterationByReference assigns the issue identified by its repository and number to the
+// iteration. The issue is retrieved using the clients of the user
+func (app *Application) AssignIterationByReference(clients *GitHubClients, workspace *Workspace, iteration *Iteration, repository string, number int) (err error) {
+	var (
+		repo  *github.Repository
+		issue *github.Issue
+	)
+
+	if repo, issue, err = app.getWorkspaceIssue(clients, workspace, repository, number); err != nil {
+		return err
+	}
+
+	return app.AssignIteration(iteration, repo, issue)
+}
+
+// UnassignIteration removes the issue from the iteration
+func (app *Application) UnassignIteration(iteration *Iteration, issueID string) error {
+	return app.db.Delete(&IterationIssue{}, "iteration_id = ? AND issue_id = ?", iteration.ID, issueID)
+}
+
+// AssignIterationByName assigns the issue to the iteration with the specified name in all
+// workspaces containing its repository. This is used by the /sprint command
+func (app *Application) AssignIterationByName(clients *GitHubClients, repo *github.Repository, issue *github.Issue, name string) (err error) {
+	var (
+		workspaces []*Workspace
+		iterations []*Iteration
+		assigned   bool
+	)
+
+	if workspaces, err = app.db.GetWorkspaces("? = ANY(repository_ids)", repo.GetID()); err != nil {
+		return fmt.Errorf("Could not fetch workspaces from database: %w", err)
+	}
+
+	for _, workspace := range workspaces {
+		if iterations, err = app.db.GetIterations("workspace_id = ? AND name = ?", workspace.ID, name); err != nil {
+			return err
+		}
+
+		for _, iteration := range iterations {
+			if err = app.AssignIteration(iteration, repo, issue); err != nil {
+				return err
+			}
+
+			assigned = true
+		}
+	}
+
+	if assigned {
+		return nil
+	}
+
+	body := fmt.Sprintf("Iteration **%s** does not exist in any workspace of this repository.", name)
+
+	if _, _, err = clients.V3.Issues.CreateComment(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueComment{
+		Body: &body,
+	}); err != nil {
+		return fmt.Errorf("Creating comment for issue %s failed: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	return nil
+}
+
+// GetIterationReport returns the committed issues of the iteration together with the completed
+// work and the remaining capacity
+func (app *Application) GetIterationReport(clients *GitHubClients, iteration *Iteration) (report *IterationReport, err error) {
+	var (
+		assignments []*IterationIssue
+		ids         []string
+	)
+
+	if assignments, err = app.db.GetIterationIssues("iteration_id = ?", iteration.ID); err != nil {
+		return nil, err
+	}
+
+	for _, assignment := range assignments {
+		ids = append(ids, assignment.IssueID)
+	}
+
+	report = &IterationReport{Iteration: iteration}
+
+	if report.Issues, err = app.GetIssuesByID(clients, ids); err != nil {
+		return nil, err
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Estimate == nil {
+			continue
+		}
+
+		report.CommittedPoints += *issue.Estimate
+
+		if issue.State == StateClosed {
+			report.CompletedPoints += *issue.Estimate
+		}
+	}
+
+	report.RemainingCapacity = iteration.Capacity - report.CommittedPoints
+
+	return report, nil
+}
+
+// getWorkspaceIssue retrieves an issue of one of the repositories of the workspace
+func (app *Application) getWorkspaceIssue(clients *GitHubClients, workspace *Workspace, repository string, number int) (repo *github.Repository, issue *github.Issue, err error) {
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("%w: invalid repository %s", ErrValidationFailed, repository)
+	}
+
+	if repo, _, err = clients.V3.Repositories.Get(context.Background(), parts[0], parts[1]); err != nil {
+		return nil, nil, fmt.Errorf("Could not retrieve repository %s: %w", repository, err)
+	}
+
+	if !workspace.RepositoryIDs.Contains(repo.GetID()) {
+		return nil, nil, fmt.Errorf("%w: repository %s is not part of workspace %d", ErrValidationFailed, repository, workspace.ID)
+	}
+
+	if issue, _, err = clients.V3.Issues.Get(context.Background(), parts[0], parts[1], number); err != nil {
+		return nil, nil, fmt.Errorf("Could not retrieve issue %s#%d: %w", repository, number, err)
+	}
+
+	return repo, issue, nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+var labelColor = regexp.MustCompile("^[0-9a-f]{6}$")
+
+// WorkspaceLabel is a label that should exist in all repositories of a workspace
+type WorkspaceLabel struct {
+	WorkspaceID int64  `json:"workspaceID" gorm:"primary_key;auto_increment:false"`
+	Name        string `json:"name" gorm:"primary_key"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// LabelSyncReport contains the differences between the labels of a repository and the labels
+// of its workspace, which are or would be fixed by a label sync
+type LabelSyncReport struct {
+	Repository string   `json:"repository"`
+	Created    []string `json:"created"`
+	Updated    []string `json:"updated"`
+	Deleted    []string `json:"deleted"`
+}
+
+// Validate checks, whether the label can be stored
+func (l *WorkspaceLabel) Validate() error {
+	l.Name = strings.TrimSpace(l.Name)
+	l.Color = strings.ToLower(strings.TrimPrefix(l.Color, "#"))
+
+	if l.Name == "" {
+		return fmt.Errorf("%w: label name must not be empty", ErrValidationFailed)
+	}
+
+	if !labelColor.MatchString(l.Color) {
+		return fmt.Errorf("%w: label %s needs a color in hex notation", ErrValidationFailed, l.Name)
+	}
+
+	return nil
+}
+
+// GetWorkspaceLabels returns the label set of the workspace
+func (app *Application) GetWorkspaceLabels(workspaceID int64) ([]*WorkspaceLabel, error) {
+	return app.db.GetWorkspaceLabels("workspace_id = ?", workspaceID)
+}
+
+// SetWorkspaceLabels replaces the label set of the workspace
+func (app *Application) SetWorkspaceLabels(workspaceID int64, labels []*WorkspaceLabel) (err error) {
+	names := make(map[string]bool)
+
+	for _, label := range labels {
+		if err = label.Validate(); err != nil {
+			return err
+		}
+
+		if names[strings.ToLower(label.Name)] {
+			return fmt.Errorf("%w: duplicate label %s", ErrValidationFailed, label.Name)
+		}
+
+		names[strings.ToLower(label.Name)] = true
+		label.WorkspaceID = workspaceID
+	}
+
+	if err = app.db.Delete(&WorkspaceLabel{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	for _, label := range labels {
+		if err = app.db.Insert(label); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncLabels creates and updates the labels of the workspace in all of its repositories using the
+// installation clients of our app. If prune is set, labels not part of the workspace label set are
+// deleted. In a dry run, only the differences are reported and the user of the supplied clients
+// needs to be able to see all repositories. Otherwise, the user needs to be able to push to all of them
+func (app *Application) SyncLabels(userClients *GitHubClients, workspace *Workspace, prune bool, dryRun bool) (reports []*LabelSyncReport, err error) {
+	var (
+		labels  []*WorkspaceLabel
+		clients *GitHubClients
+		repo    *github.Repository
+		report  *LabelSyncReport
+		allowed bool
+	)
+
+	if dryRun {
+		if allowed, err = app.CanAccessWorkspace(userClients, workspace); err != nil {
+			return nil, err
+		}
+
+		if !allowed {
+			return nil, fmt.Errorf("%w: labels can only be compared by users with access to all repositories", ErrAccessDenied)
+		}
+	} else {
+		if allowed, err = app.CanPushRepositories(userClients, workspace.RepositoryIDs); err != nil {
+			return nil, err
+		}
+
+		if !allowed {
+			return nil, fmt.Errorf("%w: labels can only be synchronized by users with push access to all repositories", ErrAccessDenied)
+		}
+	}
+
+	if labels, err = app.GetWorkspaceLabels(workspace.ID); err != nil {
+		return nil, err
+	}
+
+	reports = []*LabelSyncReport{}
+
+	for _, repositoryID := range workspace.RepositoryIDs {
+		if clients, err = app.GetRepositoryInstallationClients(repositoryID); err != nil {
+			return nil, err
+		}
+
+		if repo, _, err = clients.V3.Repositories.GetByID(context.Background(), repositoryID); err != nil {
+			return nil, fmt.Errorf("Could not retrieve repository %d: %w", repositoryID, err)
+		}
+
+		if report, err = app.syncRepositoryLabels(clients, repo, labels, prune, dryRun); err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (app *Application) syncRepositoryLabels(clients *GitHubClients, repo *github.Repository, labels []*WorkspaceLabel, prune bool, dryRun bool) (report *LabelSyncReport, err error) {
+	var existing []*github.Label
+
+	owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+	if existing, err = listLabels(clients, owner, name); err != nil {
+		return nil, err
+	}
+
+	report = &LabelSyncReport{
+		Repository: repo.GetFullName(),
+		Created:    []string{},
+		Updated:    []string{},
+		Deleted:    []string{},
+	}
+
+	byName := make(map[string]*github.Label)
+	for _, label := range existing {
+		byName[strings.ToLower(label.GetName())] = label
+	}
+
+	for _, label := range labels {
+		request := &github.Label{
+			Name:        github.String(label.Name),
+			Color:       github.String(label.Color),
+			Description: github.String(label.Description),
+		}
+
+		current, found := byName[strings.ToLower(label.Name)]
+		delete(byName, strings.ToLower(label.Name))
+
+		if !found {
+			report.Created = append(report.Created, label.Name)
+
+			if !dryRun {
+				if _, _, err = clients.V3.Issues.CreateLabel(context.Background(), owner, name, request); err != nil {
+					return nil, fmt.Errorf("Could not create label %s in %s: %w", label.Name, repo.GetFullName(), err)
+				}
+			}
+
+			continue
+		}
+
+		if current.GetName() == label.Name && strings.EqualFold(current.GetColor(), label.Color) && current.GetDescription() == label.Description {
+			continue
+		}
+
+		report.Updated = append(report.Updated, label.Name)
+
+		if !dryRun {
+			if _, _, err = clients.V3.Issues.EditLabel(context.Background(), owner, name, current.GetName(), request); err != nil {
+				return nil, fmt.Errorf("Could not update label %s in %s: %w", label.Name, repo.GetFullName(), err)
+			}
+		}
+	}
+
+	if !prune {
+		return report, nil
+	}
+
+	// all labels left are not part of the workspace
+	for _, label := range byName {
+		report.Deleted = append(report.Deleted, label.GetName())
+
+		if !dryRun {
+			if _, err = clients.V3.Issues.DeleteLabel(context.Background(), owner, name, label.GetName()); err != nil {
+				return nil, fmt.Errorf("Could not delete label %s in %s: %w", label.GetName(), repo.GetFullName(), err)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func listLabels(clients *GitHubClients, owner string, name string) (labels []*github.Label, err error) {
+	options := github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := clients.V3.Issues.ListLabels(context.Background(), owner, name, &options)
+		if err != nil {
+			return nil, fmt.Errorf("Could not list labels of %s/%s: %w", owner, name, err)
+		}
+
+		labels = append(labels, page...)
+
+		if resp.NextPage == 0 {
+			return labels, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v29/github"
+)
+
+// Role is the role of a user within a workspace
+type Role string
+
+const (
+	// RoleNone is the role of users that cannot access the workspace at all
+	RoleNone Role = ""
+	// RoleViewer can view the workspace and its issues
+	RoleViewer Role = "viewer"
+	// RoleMaintainer can additionally change the settings of the workspace and order the backlog
+	RoleMaintainer Role = "maintainer"
+	// RoleOwner can additionally manage owners and delete the workspace
+	RoleOwner Role = "owner"
+)
+
+// WorkspaceMember is a user that was explicitly added to a workspace with a certain role
+type WorkspaceMember struct {
+	WorkspaceID int64  `json:"workspaceID" gorm:"primary_key;auto_increment:false"`
+	UserID      int64  `json:"userID" gorm:"primary_key;auto_increment:false"`
+	Login       string `json:"login"`
+	Role        Role   `json:"role"`
+}
+
+func (r Role) level() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleMaintainer:
+		return 2
+	case RoleOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Includes checks, whether the role grants at least the permissions of the other role
+func (r Role) Includes(other Role) bool {
+	return r.level() >= other.level()
+}
+
+// Validate checks, whether the role is a valid role for a member
+func (r Role) Validate() error {
+	if r.level() == 0 {
+		return fmt.Errorf("%w: invalid role '%s'", ErrValidationFailed, r)
+	}
+
+	return nil
+}
+
+// GetRole returns the role of the user of the clients within the workspace. Explicit members
+// have their assigned role, all other users that can see the repositories of the workspace are
+// viewers
+func (app *Application) GetRole(clients *GitHubClients, workspace *Workspace) (role Role, err error) {
+	var (
+		members []*WorkspaceMember
+		ok      bool
+	)
+
+	if members, err = app.db.GetWorkspaceMembers("workspace_id = ? AND user_id = ?", workspace.ID, clients.User.GetID()); err != nil {
+		return RoleNone, fmt.Errorf("Could not fetch workspace members from database: %w", err)
+	}
+
+	if len(members) > 0 {
+		return members[0].Role, nil
+	}
+
+	// workspaces without repositories are only visible to their members
+	if len(workspace.RepositoryIDs) == 0 {
+		return RoleNone, nil
+	}
+
+	if ok, err = app.CanAccessWorkspace(clients, workspace); err != nil || !ok {
+		return RoleNone, err
+	}
+
+	return RoleViewer, nil
+}
+
+// CheckRole returns ErrAccessDenied, if the user of the clients does not have at least the
+// specified role within the workspace
+func (app *Application) CheckRole(clients *GitHubClients, workspace *Workspace, required Role) (err error) {
+	var role Role
+
+	if role, err = app.GetRole(clients, workspace); err != nil {
+		return err
+	}
+
+	if role == RoleNone || !role.Includes(required) {
+		return fmt.Errorf("%w: user %s needs role %s in workspace %d", ErrAccessDenied, clients.User.GetLogin(), required, workspace.ID)
+	}
+
+	return nil
+}
+
+// GetWorkspaceMembers returns all explicit members of the workspace
+func (app *Application) GetWorkspaceMembers(workspaceID int64) ([]*WorkspaceMember, error) {
+	return app.db.GetWorkspaceMembers("workspace_id = ?", workspaceID)
+}
+
+// InviteMember adds the GitHub user with the specified login to the workspace or changes the role
+// of an existing member. Only owners can grant the owner role
+func (app *Application) InviteMember(clients *GitHubClients, workspace *Workspace, login string, role Role) (member *WorkspaceMember, err error) {
+	var (
+		user     *github.User
+		resp     *github.Response
+		members  []*WorkspaceMember
+		inviting Role
+	)
+
+	if err = role.Validate(); err != nil {
+		return nil, err
+	}
+
+	if inviting, err = app.GetRole(clients, workspace); err != nil {
+		return nil, err
+	}
+
+	if !inviting.Includes(role) {
+		return nil, fmt.Errorf("%w: user %s cannot grant role %s", ErrAccessDenied, clients.User.GetLogin(), role)
+	}
+
+	if user, resp, err = clients.V3.Users.Get(context.Background(), login); err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return nil, fmt.Errorf("%w: GitHub user %s does not exist", ErrValidationFailed, login)
+		}
+
+		return nil, fmt.Errorf("Could not retrieve GitHub user %s: %w", login, err)
+	}
+
+	if members, err = app.db.GetWorkspaceMembers("workspace_id = ? AND user_id = ?", workspace.ID, user.GetID()); err != nil {
+		return nil, fmt.Errorf("Could not fetch workspace members from database: %w", err)
+	}
+
+	member = &WorkspaceMember{
+		WorkspaceID: workspace.ID,
+		UserID:      user.GetID(),
+		Login:       user.GetLogin(),
+		Role:        role,
+	}
+
+	if len(members) == 0 {
+		err = app.db.Insert(member)
+	} else {
+		// nobody but owners can demote owners
+		if members[0].Role == RoleOwner && inviting != RoleOwner {
+			return nil, fmt.Errorf("%w: user %s cannot change the role of owner %s", ErrAccessDenied, clients.User.GetLogin(), login)
+		}
+
+		_, err = app.db.Update(member)
+	}
+
+	return member, err
+}
+
+// RemoveMember removes the explicit membership of a user from the workspace. Owners can only be
+// removed by other owners and the last owner cannot be removed at all
+func (app *Application) RemoveMember(clients *GitHubClients, workspace *Workspace, userID int64) (err error) {
+	var (
+		members  []*WorkspaceMember
+		removing Role
+		owners   int
+		member   *WorkspaceMember
+	)
+
+	if removing, err = app.GetRole(clients, workspace); err != nil {
+		return err
+	}
+
+	if members, err = app.GetWorkspaceMembers(workspace.ID); err != nil {
+		return fmt.Errorf("Could not fetch workspace members from database: %w", err)
+	}
+
+	for _, m := range members {
+		if m.Role == RoleOwner {
+			owners++
+		}
+
+		if m.UserID == userID {
+			member = m
+		}
+	}
+
+	if member == nil {
+		return nil
+	}
+
+	if member.Role == RoleOwner {
+		if removing != RoleOwner {
+			return fmt.Errorf("%w: user %s cannot remove owner %s", ErrAccessDenied, clients.User.GetLogin(), member.Login)
+		}
+
+		if owners == 1 {
+			return fmt.Errorf("%w: the last owner of a workspace cannot be removed", ErrValidationFailed)
+		}
+	}
+
+	return app.db.Delete(member)
+}
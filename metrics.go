@@ -0,0 +1,429 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// MetricsDefaultWindow is the time window of the metrics, if none is specified
+const MetricsDefaultWindow = 30 * 24 * time.Hour
+
+// MetricsPercentiles are the percentiles computed for lead and cycle times
+var MetricsPercentiles = []float64{50, 75, 90, 95}
+
+// IssueTimeline contains the events of a closed issue, which are relevant for its metrics
+type IssueTimeline struct {
+	Repository string
+	Number     int
+	Title      string
+	Labels     []string
+	CreatedAt  time.Time
+	ClosedAt   time.Time
+	Events     []*TimelineEvent
+}
+
+// TimelineEvent is either a label added to an issue or a pull request referencing it
+type TimelineEvent struct {
+	Time        time.Time
+	Label       string
+	PullRequest bool
+}
+
+// IssueMetrics contains the lead and cycle time of a closed issue. The lead time spans from the
+// creation to the closing of the issue, the cycle time from the start of work to the closing
+type IssueMetrics struct {
+	Repository     string     `json:"repository"`
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	Labels         []string   `json:"labels"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	ClosedAt       time.Time  `json:"closedAt"`
+	LeadTimeHours  float64    `json:"leadTimeHours"`
+	CycleTimeHours *float64   `json:"cycleTimeHours,omitempty"`
+}
+
+// MetricsSummary contains the percentiles of lead and cycle times of a group of issues, such as all
+// issues of a repository or all issues with a label. The percentiles are keyed by e.g. "p50"
+type MetricsSummary struct {
+	Group     string             `json:"group"`
+	Issues    int                `json:"issues"`
+	LeadTime  map[string]float64 `json:"leadTimeHours"`
+	CycleTime map[string]float64 `json:"cycleTimeHours"`
+}
+
+// Metrics contains the lead and cycle times of all issues of a workspace closed within a time
+// window, aggregated for the whole workspace, per repository and per label
+type Metrics struct {
+	WorkspaceID  int64             `json:"workspaceID"`
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	Issues       []*IssueMetrics   `json:"issues"`
+	Workspace    *MetricsSummary   `json:"workspace"`
+	Repositories []*MetricsSummary `json:"repositories"`
+	Labels       []*MetricsSummary `json:"labels"`
+}
+
+// ComputeIssueMetrics computes the metrics of a closed issue. Work on an issue starts with its
+// first pull request or when it first received one of the specified labels
+func ComputeIssueMetrics(timeline *IssueTimeline, inProgressLabels []string) *IssueMetrics {
+	metrics := &IssueMetrics{
+		Repository:    timeline.Repository,
+		Number:        timeline.Number,
+		Title:         timeline.Title,
+		Labels:        timeline.Labels,
+		CreatedAt:     timeline.CreatedAt,
+		ClosedAt:      timeline.ClosedAt,
+		LeadTimeHours: timeline.ClosedAt.Sub(timeline.CreatedAt).Hours(),
+	}
+
+	for _, event := range timeline.Events {
+		if !event.PullRequest && !containsFold(inProgressLabels, event.Label) {
+			continue
+		}
+
+		// events after closing, e.g. of a re-opened issue, do not start its cycle
+		if event.Time.After(timeline.ClosedAt) {
+			continue
+		}
+
+		if metrics.StartedAt == nil || event.Time.Before(*metrics.StartedAt) {
+			started := event.Time
+			metrics.StartedAt = &started
+		}
+	}
+
+	if metrics.StartedAt != nil {
+		hours := timeline.ClosedAt.Sub(*metrics.StartedAt).Hours()
+		metrics.CycleTimeHours = &hours
+	}
+
+	return metrics
+}
+
+// Percentile returns the p-th percentile of the values using the nearest-rank method
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// SummarizeMetrics computes the percentiles of lead and cycle times of the issues
+func SummarizeMetrics(group string, issues []*IssueMetrics) *MetricsSummary {
+	var leadTimes, cycleTimes []float64
+
+	for _, issue := range issues {
+		leadTimes = append(leadTimes, issue.LeadTimeHours)
+
+		if issue.CycleTimeHours != nil {
+			cycleTimes = append(cycleTimes, *issue.CycleTimeHours)
+		}
+	}
+
+	summary := &MetricsSummary{
+		Group:     group,
+		Issues:    len(issues),
+		LeadTime:  make(map[string]float64),
+		CycleTime: make(map[string]float64),
+	}
+
+	for _, p := range MetricsPercentiles {
+		key := fmt.Sprintf("p%v", p)
+
+		summary.LeadTime[key] = Percentile(leadTimes, p)
+		summary.CycleTime[key] = Percentile(cycleTimes, p)
+	}
+
+	return summary
+}
+
+// AggregateMetrics aggregates the metrics of the issues for the workspace, per repository and
+// per label. Groups are sorted by their name
+func AggregateMetrics(metrics *Metrics) {
+	repositories := make(map[string][]*IssueMetrics)
+	labels := make(map[string][]*IssueMetrics)
+
+	for _, issue := range metrics.Issues {
+		repositories[issue.Repository] = append(repositories[issue.Repository], issue)
+
+		for _, label := range issue.Labels {
+			labels[label] = append(labels[label], issue)
+		}
+	}
+
+	metrics.Workspace = SummarizeMetrics("", metrics.Issues)
+	metrics.Repositories = summarizeGroups(repositories)
+	metrics.Labels = summarizeGroups(labels)
+}
+
+func summarizeGroups(groups map[string][]*IssueMetrics) (summaries []*MetricsSummary) {
+	summaries = []*MetricsSummary{}
+
+	for group, issues := range groups {
+		summaries = append(summaries, SummarizeMetrics(group, issues))
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Group < summaries[j].Group
+	})
+
+	return
+}
+
+// GetMetrics computes the lead and cycle times of all issues of the workspace, which were closed
+// within the time window
+func (app *Application) GetMetrics(clients *GitHubClients, workspace *Workspace, from time.Time, to time.Time) (metrics *Metrics, err error) {
+	var (
+		repositories []*github.Repository
+		timelines    []*IssueTimeline
+		labels       []string
+	)
+
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: the end of the time window needs to be after its start", ErrValidationFailed)
+	}
+
+	if labels, err = app.inProgressLabels(workspace.ID); err != nil {
+		return nil, err
+	}
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return nil, err
+	}
+
+	metrics = &Metrics{WorkspaceID: workspace.ID, From: from, To: to, Issues: []*IssueMetrics{}}
+
+	for _, repo := range repositories {
+		if timelines, err = queryClosedIssueTimelines(clients, repo, from); err != nil {
+			return nil, err
+		}
+
+		for _, timeline := range timelines {
+			if timeline.ClosedAt.Before(from) || timeline.ClosedAt.After(to) {
+				continue
+			}
+
+			metrics.Issues = append(metrics.Issues, ComputeIssueMetrics(timeline, labels))
+		}
+	}
+
+	AggregateMetrics(metrics)
+
+	return metrics, nil
+}
+
+// inProgressLabels returns the labels, which indicate that work on an issue has started. Besides
+// the status labels of pull requests, these are the labels of all board columns except the first
+// one and those for closed issues
+func (app *Application) inProgressLabels(workspaceID int64) (labels []string, err error) {
+	var columns []*BoardColumn
+
+	if columns, err = app.GetBoardColumns(workspaceID); err != nil {
+		return nil, err
+	}
+
+	labels = []string{LabelInProgress, LabelInReview}
+
+	for i, column := range columns {
+		if i == 0 || column.Label == "" || column.State == StateClosed {
+			continue
+		}
+
+		labels = append(labels, column.Label)
+	}
+
+	return labels, nil
+}
+
+// timelineItemsConnection contains the events of an issue relevant for its metrics. Issues can have
+// more events than fit in one page, so the remaining ones are retrieved using the cursor
+type timelineItemsConnection struct {
+	Nodes []struct {
+		Typename     string `graphql:"__typename"`
+		LabeledEvent struct {
+			CreatedAt githubv4.DateTime
+			Label     struct {
+				Name string
+			}
+		} `graphql:"... on LabeledEvent"`
+		CrossReferencedEvent struct {
+			CreatedAt githubv4.DateTime
+			Source    struct {
+				PullRequest struct {
+					Number int
+				} `graphql:"... on PullRequest"`
+			}
+		} `graphql:"... on CrossReferencedEvent"`
+		ConnectedEvent struct {
+			CreatedAt githubv4.DateTime
+		} `graphql:"... on ConnectedEvent"`
+	}
+	PageInfo struct {
+		EndCursor   githubv4.String
+		HasNextPage bool
+	}
+}
+
+// events returns the timeline events of the items
+func (c *timelineItemsConnection) events() (events []*TimelineEvent) {
+	for _, item := range c.Nodes {
+		switch item.Typename {
+		case "LabeledEvent":
+			events = append(events, &TimelineEvent{Time: item.LabeledEvent.CreatedAt.Time, Label: item.LabeledEvent.Label.Name})
+		case "CrossReferencedEvent":
+			// issues can be referenced by other issues as well
+			if item.CrossReferencedEvent.Source.PullRequest.Number != 0 {
+				events = append(events, &TimelineEvent{Time: item.CrossReferencedEvent.CreatedAt.Time, PullRequest: true})
+			}
+		case "ConnectedEvent":
+			events = append(events, &TimelineEvent{Time: item.ConnectedEvent.CreatedAt.Time, PullRequest: true})
+		}
+	}
+
+	return
+}
+
+// queryClosedIssueTimelines retrieves the timelines of all issues of the repository, which were
+// closed after the specified time
+func queryClosedIssueTimelines(clients *GitHubClients, repo *github.Repository, since time.Time) (timelines []*IssueTimeline, err error) {
+	var q struct {
+		Repository struct {
+			Issues struct {
+				Nodes []struct {
+					ID        githubv4.ID
+					Number    int
+					Title     string
+					CreatedAt githubv4.DateTime
+					ClosedAt  *githubv4.DateTime
+					Labels    struct {
+						Nodes []struct {
+							Name string
+						}
+					} `graphql:"labels(first: 20)"`
+					TimelineItems timelineItemsConnection `graphql:"timelineItems(first: 100, itemTypes: [LABELED_EVENT, CROSS_REFERENCED_EVENT, CONNECTED_EVENT])"`
+				}
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"issues(first: 50, after: $cursor, filterBy: $filterBy)"`
+		} `graphql:"repository(owner: $repositoryOwner, name: $repositoryName)"`
+	}
+
+	// issues closed after since have been updated after since as well
+	variables := map[string]interface{}{
+		"repositoryOwner": githubv4.String(repo.GetOwner().GetLogin()),
+		"repositoryName":  githubv4.String(repo.GetName()),
+		"filterBy": githubv4.IssueFilters{
+			States: &[]githubv4.IssueState{githubv4.IssueStateClosed},
+			Since:  githubv4.NewDateTime(githubv4.DateTime{Time: since}),
+		},
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	for {
+		if err = clients.V4.Query(context.Background(), &q, variables); err != nil {
+			return nil, fmt.Errorf("Could not query closed issues of repository %s: %w", repo.GetFullName(), err)
+		}
+
+		for _, node := range q.Repository.Issues.Nodes {
+			if node.ClosedAt == nil {
+				continue
+			}
+
+			timeline := &IssueTimeline{
+				Repository: repo.GetFullName(),
+				Number:     node.Number,
+				Title:      node.Title,
+				Labels:     []string{},
+				CreatedAt:  node.CreatedAt.Time,
+				ClosedAt:   node.ClosedAt.Time,
+				Events:     node.TimelineItems.events(),
+			}
+
+			for _, label := range node.Labels.Nodes {
+				timeline.Labels = append(timeline.Labels, label.Name)
+			}
+
+			if node.TimelineItems.PageInfo.HasNextPage {
+				var events []*TimelineEvent
+
+				if events, err = queryRemainingTimelineEvents(clients, node.ID, node.TimelineItems.PageInfo.EndCursor); err != nil {
+					return nil, err
+				}
+
+				timeline.Events = append(timeline.Events, events...)
+			}
+
+			timelines = append(timelines, timeline)
+		}
+
+		if !q.Repository.Issues.PageInfo.HasNextPage {
+			break
+		}
+
+		variables["cursor"] = githubv4.NewString(q.Repository.Issues.PageInfo.EndCursor)
+	}
+
+	return
+}
+
+// queryRemainingTimelineEvents retrieves the timeline events of the issue after the cursor
+func queryRemainingTimelineEvents(clients *GitHubClients, issueID githubv4.ID, cursor githubv4.String) (events []*TimelineEvent, err error) {
+	var q struct {
+		Node struct {
+			Issue struct {
+				TimelineItems timelineItemsConnection `graphql:"timelineItems(first: 100, after: $cursor, itemTypes: [LABELED_EVENT, CROSS_REFERENCED_EVENT, CONNECTED_EVENT])"`
+			} `graphql:"... on Issue"`
+		} `graphql:"node(id: $id)"`
+	}
+
+	variables := map[string]interface{}{
+		"id":     issueID,
+		"cursor": githubv4.NewString(cursor),
+	}
+
+	for {
+		if err = clients.V4.Query(context.Background(), &q, variables); err != nil {
+			return nil, fmt.Errorf("Could not query timeline of issue %v: %w", issueID, err)
+		}
+
+		events = append(events, q.Node.Issue.TimelineItems.events()...)
+
+		if !q.Node.Issue.TimelineItems.PageInfo.HasNextPage {
+			return events, nil
+		}
+
+		variables["cursor"] = githubv4.NewString(q.Node.Issue.TimelineItems.PageInfo.EndCursor)
+	}
+}
@@ -0,0 +1,117 @@
+package issues
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []float64{15, 20, 35, 40, 50}
+
+	for p, expected := range map[float64]float64{5: 15, 30: 20, 40: 20, 50: 35, 100: 50} {
+		if actual := Percentile(values, p); actual != expected {
+			t.Errorf("Expected p%v to be %v, got %v", p, expected, actual)
+		}
+	}
+
+	if actual := Percentile(nil, 50); actual != 0 {
+		t.Errorf("Expected 0 for no values, got %v", actual)
+	}
+}
+
+func TestComputeIssueMetrics(t *testing.T) {
+	created := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	timeline := &IssueTimeline{
+		CreatedAt: created,
+		ClosedAt:  created.Add(72 * time.Hour),
+		Events: []*TimelineEvent{
+			{Time: created.Add(time.Hour), Label: "bug"},
+			{Time: created.Add(48 * time.Hour), PullRequest: true},
+			{Time: created.Add(24 * time.Hour), Label: "Doing"},
+			{Time: created.Add(96 * time.Hour), Label: LabelInProgress},
+		},
+	}
+
+	metrics := ComputeIssueMetrics(timeline, []string{LabelInProgress, "doing"})
+	if metrics.LeadTimeHours != 72 || metrics.CycleTimeHours == nil || *metrics.CycleTimeHours != 48 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+
+	timeline.Events = timeline.Events[:1]
+	if metrics = ComputeIssueMetrics(timeline, []string{LabelInProgress}); metrics.CycleTimeHours != nil {
+		t.Errorf("Expected no cycle time, got %v", *metrics.CycleTimeHours)
+	}
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	ten := 10.0
+
+	metrics := &Metrics{Issues: []*IssueMetrics{
+		{Repository: "aybaze/api", Labels: []string{"bug"}, LeadTimeHours: 20, CycleTimeHours: &ten},
+		{Repository: "aybaze/frontend", Labels: []string{"bug", "ui"}, LeadTimeHours: 40},
+		{Repository: "aybaze/api", Labels: []string{}, LeadTimeHours: 60},
+	}}
+
+	AggregateMetrics(metrics)
+
+	if metrics.Workspace.Issues != 3 || metrics.Workspace.LeadTime["p50"] != 40 || metrics.Workspace.CycleTime["p95"] != 10 {
+		t.Errorf("Unexpected workspace summary %+v", metrics.Workspace)
+	}
+
+	if len(metrics.Repositories) != 2 || metrics.Repositories[0].Group != "aybaze/api" || metrics.Repositories[0].Issues != 2 {
+		t.Errorf("Unexpected repository summaries %+v", metrics.Repositories)
+	}
+
+	if len(metrics.Labels) != 2 || metrics.Labels[0].Group != "bug" || metrics.Labels[1].LeadTime["p90"] != 40 {
+		t.Errorf("Unexpected label summaries %+v", metrics.Labels)
+	}
+}
+
+func TestQueryClosedIssueTimelines(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		// the remaining events of the issue are retrieved by its ID
+		if strings.Contains(string(body), "node(id:") {
+			fmt.Fprint(w, `{"data": {"node": {"timelineItems": {
+				"nodes": [{"__typename": "ConnectedEvent", "createdAt": "2020-03-03T00:00:00Z"}],
+				"pageInfo": {"endCursor": "b", "hasNextPage": false}}}}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"data": {"repository": {"issues": {
+			"nodes": [{"id": "issue", "number": 1, "title": "Crash", "createdAt": "2020-03-01T00:00:00Z", "closedAt": "2020-03-04T00:00:00Z",
+				"labels": {"nodes": []},
+				"timelineItems": {
+					"nodes": [{"__typename": "LabeledEvent", "createdAt": "2020-03-02T00:00:00Z", "label": {"name": "bug"}}],
+					"pageInfo": {"endCursor": "a", "hasNextPage": true}}}],
+			"pageInfo": {"endCursor": "c", "hasNextPage": false}}}}}`)
+	}))
+	defer server.Close()
+
+	clients := &GitHubClients{V4: githubv4.NewEnterpriseClient(server.URL, server.Client())}
+	owner, name, fullName := "owner", "repo", "owner/repo"
+	repo := &github.Repository{Owner: &github.User{Login: &owner}, Name: &name, FullName: &fullName}
+
+	timelines, err := queryClosedIssueTimelines(clients, repo, time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Could not query timelines: %s", err)
+	}
+
+	if len(timelines) != 1 || len(timelines[0].Events) != 2 {
+		t.Fatalf("Expected one timeline with events of both pages, got %+v", timelines)
+	}
+
+	if timelines[0].Events[0].Label != "bug" || !timelines[0].Events[1].PullRequest {
+		t.Errorf("Unexpected events %+v, %+v", timelines[0].Events[0], timelines[0].Events[1])
+	}
+}
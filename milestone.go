@@ -0,0 +1,315 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// WorkspaceMilestone is a milestone spanning all repositories of a workspace. It is kept in sync
+// with a milestone of the same title in each repository
+type WorkspaceMilestone struct {
+	ID          int64      `json:"id"`
+	WorkspaceID int64      `json:"workspaceID"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueOn       *time.Time `json:"dueOn"`
+	State       string     `json:"state"`
+}
+
+// Validate checks, whether the milestone can be stored
+func (m *WorkspaceMilestone) Validate() error {
+	m.Title = strings.TrimSpace(m.Title)
+
+	if m.Title == "" {
+		return fmt.Errorf("%w: milestone title must not be empty", ErrValidationFailed)
+	}
+
+	if m.State == "" {
+		m.State = StateOpen
+	}
+
+	if m.State != StateOpen && m.State != StateClosed {
+		return fmt.Errorf("%w: invalid milestone state %s", ErrValidationFailed, m.State)
+	}
+
+	return nil
+}
+
+// GetWorkspaceMilestones returns all milestones of the workspace
+func (app *Application) GetWorkspaceMilestones(workspaceID int64) ([]*WorkspaceMilestone, error) {
+	return app.db.GetWorkspaceMilestones("workspace_id = ?", workspaceID)
+}
+
+// GetWorkspaceMilestone returns the milestone of the workspace with the specified title or nil,
+// if it does not exist
+func (app *Application) GetWorkspaceMilestone(workspaceID int64, title string) (*WorkspaceMilestone, error) {
+	milestones, err := app.db.GetWorkspaceMilestones("workspace_id = ? AND title = ?", workspaceID, title)
+	if err != nil || len(milestones) == 0 {
+		return nil, err
+	}
+
+	return milestones[0], nil
+}
+
+// CreateWorkspaceMilestone creates a new milestone in all repositories of the workspace and stores it
+func (app *Application) CreateWorkspaceMilestone(clients *GitHubClients, workspace *Workspace, milestone *WorkspaceMilestone) (err error) {
+	var existing *WorkspaceMilestone
+
+	if err = milestone.Validate(); err != nil {
+		return err
+	}
+
+	if existing, err = app.GetWorkspaceMilestone(workspace.ID, milestone.Title); err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("%w: milestone %s already exists", ErrValidationFailed, milestone.Title)
+	}
+
+	milestone.ID = 0
+	milestone.WorkspaceID = workspace.ID
+
+	// the milestone is only stored once it exists in all repositories, so that a failed sync can
+	// simply be retried
+	if err = app.SyncWorkspaceMilestone(clients, workspace, milestone, milestone.Title); err != nil {
+		return err
+	}
+
+	return app.db.Insert(milestone)
+}
+
+// UpdateWorkspaceMilestone updates the milestone in all repositories of the workspace and stores
+// it. The previous title is needed to find the milestones in the repositories, if the
+// milestone was renamed
+func (app *Application) UpdateWorkspaceMilestone(clients *GitHubClients, workspace *Workspace, milestone *WorkspaceMilestone, previousTitle string) (err error) {
+	var existing *WorkspaceMilestone
+
+	if err = milestone.Validate(); err != nil {
+		return err
+	}
+
+	if milestone.Title != previousTitle {
+		if existing, err = app.GetWorkspaceMilestone(workspace.ID, milestone.Title); err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return fmt.Errorf("%w: milestone %s already exists", ErrValidationFailed, milestone.Title)
+		}
+	}
+
+	if err = app.SyncWorkspaceMilestone(clients, workspace, milestone, previousTitle); err != nil {
+		return err
+	}
+
+	_, err = app.db.Update(milestone)
+
+	return
+}
+
+// SyncWorkspaceMilestone creates or updates the milestone in all repositories of the workspace, so
+// that title, description, due date and state are the same everywhere
+func (app *Application) SyncWorkspaceMilestone(clients *GitHubClients, workspace *Workspace, milestone *WorkspaceMilestone, previousTitle string) (err error) {
+	var (
+		repositories []*github.Repository
+		existing     *github.Milestone
+	)
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return err
+	}
+
+	for _, repo := range repositories {
+		owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+
+		if existing, err = FindRepositoryMilestone(clients, owner, name, previousTitle); err != nil {
+			return err
+		}
+
+		if existing == nil && previousTitle != milestone.Title {
+			if existing, err = FindRepositoryMilestone(clients, owner, name, milestone.Title); err != nil {
+				return err
+			}
+		}
+
+		request := &github.Milestone{
+			Title:       &milestone.Title,
+			Description: &milestone.Description,
+			DueOn:       milestone.DueOn,
+			State:       &milestone.State,
+		}
+
+		if existing == nil {
+			if _, _, err = clients.V3.Issues.CreateMilestone(context.Background(), owner, name, request); err != nil {
+				return fmt.Errorf("Could not create milestone %s in %s: %w", milestone.Title, repo.GetFullName(), err)
+			}
+
+			log.Infof("Created milestone %s in %s", milestone.Title, repo.GetFullName())
+			continue
+		}
+
+		if milestoneEquals(existing, milestone) {
+			continue
+		}
+
+		if _, _, err = clients.V3.Issues.EditMilestone(context.Background(), owner, name, existing.GetNumber(), request); err != nil {
+			return fmt.Errorf("Could not update milestone %s in %s: %w", milestone.Title, repo.GetFullName(), err)
+		}
+
+		log.Infof("Updated milestone %s in %s", milestone.Title, repo.GetFullName())
+	}
+
+	return nil
+}
+
+func milestoneEquals(existing *github.Milestone, milestone *WorkspaceMilestone) bool {
+	if existing.GetTitle() != milestone.Title ||
+		existing.GetDescription() != milestone.Description ||
+		existing.GetState() != milestone.State {
+		return false
+	}
+
+	// GitHub only stores the date of the due date
+	if existing.DueOn == nil || milestone.DueOn == nil {
+		return existing.DueOn == nil && milestone.DueOn == nil
+	}
+
+	return existing.DueOn.UTC().Format("2006-01-02") == milestone.DueOn.UTC().Format("2006-01-02")
+}
+
+// FindRepositoryMilestone returns the milestone of the repository with the specified title or nil,
+// if it does not exist
+func FindRepositoryMilestone(clients *GitHubClients, owner string, name string, title string) (*github.Milestone, error) {
+	milestones, err := listMilestones(clients, owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, milestone := range milestones {
+		if milestone.GetTitle() == title {
+			return milestone, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// listMilestones returns all open and closed milestones of the repository
+func listMilestones(clients *GitHubClients, owner string, name string) (milestones []*github.Milestone, err error) {
+	options := github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		result, resp, err := clients.V3.Issues.ListMilestones(context.Background(), owner, name, &options)
+		if err != nil {
+			return nil, fmt.Errorf("Could not list milestones of %s/%s: %w", owner, name, err)
+		}
+
+		milestones = append(milestones, result...)
+
+		if resp.NextPage == 0 {
+			return milestones, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+// QueryMilestoneIssues retrieves the issues of the workspace milestone with the specified title,
+// which match the filter. Milestones can only be filtered by their number, which differs between
+// repositories, so it is looked up by the title in each repository. Repositories without the
+// milestone are skipped
+func (app *Application) QueryMilestoneIssues(clients *GitHubClients, workspace *Workspace, title string, filter githubv4.IssueFilters) (issues []*BacklogIssue, err error) {
+	var (
+		repositories []*github.Repository
+		milestone    *github.Milestone
+		result       []*BacklogIssue
+	)
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return nil, err
+	}
+
+	issues = []*BacklogIssue{}
+
+	for _, repo := range repositories {
+		if milestone, err = FindRepositoryMilestone(clients, repo.GetOwner().GetLogin(), repo.GetName(), title); err != nil {
+			return nil, err
+		}
+
+		if milestone == nil {
+			continue
+		}
+
+		filter.Milestone = githubv4.NewString(githubv4.String(strconv.Itoa(milestone.GetNumber())))
+
+		if result, err = app.queryIssues(clients, repo, filter); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, result...)
+	}
+
+	if err = app.annotateIssues(workspace.ID, issues); err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// AssignMilestone assigns the issue to the milestone with the specified title in its repository.
+// This is used by the /milestone command
+func (app *Application) AssignMilestone(clients *GitHubClients, repo *github.Repository, issue *github.Issue, title string) (err error) {
+	var milestone *github.Milestone
+
+	if milestone, err = FindRepositoryMilestone(clients, repo.GetOwner().GetLogin(), repo.GetName(), title); err != nil {
+		return err
+	}
+
+	if milestone == nil {
+		body := fmt.Sprintf("Milestone **%s** does not exist in this repository.", title)
+
+		if _, _, err = clients.V3.Issues.CreateComment(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueComment{
+			Body: &body,
+		}); err != nil {
+			return fmt.Errorf("Creating comment for issue %s failed: %w", GetIssueIdentifier(repo, issue), err)
+		}
+
+		return nil
+	}
+
+	number := milestone.GetNumber()
+
+	if _, _, err = clients.V3.Issues.Edit(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueRequest{
+		Milestone: &number,
+	}); err != nil {
+		return fmt.Errorf("Could not assign %s to milestone %s: %w", GetIssueIdentifier(repo, issue), title, err)
+	}
+
+	log.Infof("Assigned issue %s to milestone %s", GetIssueIdentifier(repo, issue), title)
+
+	return nil
+}
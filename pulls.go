@@ -0,0 +1,295 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+)
+
+const (
+	// RelationshipBlockedBy is the type of a relationship, in which the issue cannot be
+	// finished before the other issue is closed
+	RelationshipBlockedBy = "blocked-by"
+
+	// BlockerStatusContext is the context of the commit status we set on pull requests
+	BlockerStatusContext = "issues/blockers"
+
+	// LabelInProgress is the label of issues, which have an open draft pull request
+	LabelInProgress = "in progress"
+
+	// LabelInReview is the label of issues, which have an open pull request ready for review
+	LabelInReview = "in review"
+)
+
+var (
+	closingKeywords   = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+#([0-9]+)\b`)
+	branchIssueNumber = regexp.MustCompile(`^([0-9]+)-`)
+)
+
+// ClosingIssueNumbers returns the numbers of all issues that are closed by a pull request
+// with the given body, using the closing keywords of GitHub, such as 'fixes #12'
+func ClosingIssueNumbers(body string) (numbers []int) {
+	for _, match := range closingKeywords.FindAllStringSubmatch(body, -1) {
+		i, _ := strconv.Atoi(match[1])
+		numbers = append(numbers, i)
+	}
+
+	return
+}
+
+// LinkedIssueNumbers returns the numbers of all issues linked to the pull request. An issue is
+// linked, if it is closed by the pull request or if the head branch follows the naming scheme
+// <number>-<slug> of the /branch command
+func LinkedIssueNumbers(pull *github.PullRequest) (numbers []int) {
+	numbers = ClosingIssueNumbers(pull.GetBody())
+
+	match := branchIssueNumber.FindStringSubmatch(pull.GetHead().GetRef())
+	if len(match) != 2 {
+		return
+	}
+
+	i, _ := strconv.Atoi(match[1])
+	for _, number := range numbers {
+		if number == i {
+			return
+		}
+	}
+
+	return append(numbers, i)
+}
+
+// UpdateBlockerStatus sets a commit status on the head of the pull request, which fails as
+// long as any issue linked to the pull request is blocked by another open issue. Only
+// relationships of the repository of the pull request are considered
+func (app *Application) UpdateBlockerStatus(clients *GitHubClients, repo *github.Repository, pull *github.PullRequest) (err error) {
+	var (
+		relationships []*Relationship
+		blocker       *github.Issue
+		blockers      []string
+	)
+
+	for _, number := range LinkedIssueNumbers(pull) {
+		if relationships, err = app.db.GetRelationships("repository_id = ? AND issue_id = ? AND type = ?", repo.GetID(), number, RelationshipBlockedBy); err != nil {
+			return fmt.Errorf("Could not fetch relationships to other issues from database: %w", err)
+		}
+
+		for _, relationship := range relationships {
+			if blocker, _, err = clients.V3.Issues.Get(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), int(relationship.OtherIssueID)); err != nil {
+				return fmt.Errorf("Could not retrieve blocking issue #%d: %w", relationship.OtherIssueID, err)
+			}
+
+			if blocker.GetState() == "open" {
+				blockers = append(blockers, fmt.Sprintf("#%d", blocker.GetNumber()))
+			}
+		}
+	}
+
+	state := "success"
+	description := "No open blocking issues"
+
+	if len(blockers) > 0 {
+		state = "failure"
+		description = fmt.Sprintf("Blocked by open issues %s", strings.Join(blockers, ", "))
+	}
+
+	statusContext := BlockerStatusContext
+	status := github.RepoStatus{
+		State:       &state,
+		Description: &description,
+		Context:     &statusContext,
+	}
+
+	if _, _, err = clients.V3.Repositories.CreateStatus(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), pull.GetHead().GetSHA(), &status); err != nil {
+		return fmt.Errorf("Could not set commit status for pull request #%d: %w", pull.GetNumber(), err)
+	}
+
+	log.Infof("Set blocker status of pull request %s/%s#%d to %s", repo.GetOwner().GetLogin(), repo.GetName(), pull.GetNumber(), state)
+
+	return nil
+}
+
+// UpdateBlockedPullRequests re-evaluates the blocker status of all open pull requests linked to
+// an issue which is blocked by the specified issue. It should be called whenever the blocking
+// issue is closed or re-opened
+func (app *Application) UpdateBlockedPullRequests(clients *GitHubClients, repo *github.Repository, issue *github.Issue) (err error) {
+	var (
+		relationships []*Relationship
+		pulls         []*github.PullRequest
+		resp          *github.Response
+	)
+
+	if relationships, err = app.db.GetRelationships("repository_id = ? AND other_issue_id = ? AND type = ?", repo.GetID(), issue.GetNumber(), RelationshipBlockedBy); err != nil {
+		return fmt.Errorf("Could not fetch relationships to other issues from database: %w", err)
+	}
+
+	if len(relationships) == 0 {
+		return nil
+	}
+
+	blocked := make(map[int]bool)
+	for _, relationship := range relationships {
+		blocked[int(relationship.IssueID)] = true
+	}
+
+	options := github.PullRequestListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		if pulls, resp, err = clients.V3.PullRequests.List(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), &options); err != nil {
+			return fmt.Errorf("Could not list open pull requests: %w", err)
+		}
+
+		for _, pull := range pulls {
+			for _, number := range LinkedIssueNumbers(pull) {
+				if !blocked[number] {
+					continue
+				}
+
+				if err = app.UpdateBlockerStatus(clients, repo, pull); err != nil {
+					return err
+				}
+
+				break
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		options.Page = resp.NextPage
+	}
+
+	return nil
+}
+
+// SyncPullRequest updates the issues linked to a pull request according to the pull request
+// event. The issues are labelled as in progress or in review, their items in epics are annotated
+// with the pull request and, once merged, the branch of the pull request is deleted if configured.
+// The annotation is removed again, if the pull request is closed without being merged
+func (app *Application) SyncPullRequest(clients *GitHubClients, event github.PullRequestEvent) (err error) {
+	var (
+		label string
+		issue *github.Issue
+	)
+
+	repo := event.GetRepo()
+	pull := event.GetPullRequest()
+
+	switch event.GetAction() {
+	case "opened", "reopened", "ready_for_review", "converted_to_draft":
+		if pull.GetDraft() {
+			label = LabelInProgress
+		} else {
+			label = LabelInReview
+		}
+	case "closed":
+		// no status label anymore
+	default:
+		return nil
+	}
+
+	merged := event.GetAction() == "closed" && pull.GetMerged()
+
+	for _, number := range LinkedIssueNumbers(pull) {
+		if issue, _, err = clients.V3.Issues.Get(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), number); err != nil {
+			return fmt.Errorf("Could not retrieve linked issue #%d: %w", number, err)
+		}
+
+		// pull requests can be linked to other pull requests, but we only care about issues
+		if issue.IsPullRequest() {
+			continue
+		}
+
+		if err = app.setStatusLabel(clients, repo, issue, label); err != nil {
+			return err
+		}
+
+		for _, epicNumber := range EpicNumbers(issue.GetBody()) {
+			if err = app.editEpic(clients, repo, epicNumber, func(body string) (string, IssueUpdateStatus) {
+				// a pull request closed without being merged does not implement the item anymore
+				if event.GetAction() == "closed" && !merged {
+					return RemoveEpicItemAnnotation(body, issue.GetNumber(), pull.GetNumber())
+				}
+
+				return AnnotateEpicItem(body, issue.GetNumber(), pull.GetNumber(), merged)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if merged && app.DeleteMergedBranches {
+		return app.deleteBranch(clients, repo, pull)
+	}
+
+	return nil
+}
+
+// setStatusLabel makes sure, that the issue only has the specified status label. An empty label
+// removes all status labels
+func (app *Application) setStatusLabel(clients *GitHubClients, repo *github.Repository, issue *github.Issue, label string) (err error) {
+	var found bool
+
+	for _, l := range issue.Labels {
+		if l.GetName() == label {
+			found = true
+			continue
+		}
+
+		if l.GetName() != LabelInProgress && l.GetName() != LabelInReview {
+			continue
+		}
+
+		if _, err = clients.V3.Issues.RemoveLabelForIssue(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), l.GetName()); err != nil {
+			return fmt.Errorf("Could not remove label %s from %s: %w", l.GetName(), GetIssueIdentifier(repo, issue), err)
+		}
+	}
+
+	if label == "" || found {
+		return nil
+	}
+
+	if _, _, err = clients.V3.Issues.AddLabelsToIssue(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), []string{label}); err != nil {
+		return fmt.Errorf("Could not add label %s to %s: %w", label, GetIssueIdentifier(repo, issue), err)
+	}
+
+	return nil
+}
+
+// deleteBranch deletes the head branch of a merged pull request, if it belongs to the same repository
+func (app *Application) deleteBranch(clients *GitHubClients, repo *github.Repository, pull *github.PullRequest) (err error) {
+	head := pull.GetHead()
+
+	if head.GetRepo().GetID() != repo.GetID() || head.GetRef() == repo.GetDefaultBranch() {
+		return nil
+	}
+
+	if _, err = clients.V3.Git.DeleteRef(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), fmt.Sprintf("heads/%s", head.GetRef())); err != nil {
+		return fmt.Errorf("Could not delete branch %s: %w", head.GetRef(), err)
+	}
+
+	log.Infof("Deleted branch %s of merged pull request %s/%s#%d", head.GetRef(), repo.GetOwner().GetLogin(), repo.GetName(), pull.GetNumber())
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package issues
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func TestClosingIssueNumbers(t *testing.T) {
+	body := "This Fixes #12 and closes #3.\n\nResolved: #7, but only mentions #8 and prefix#9"
+
+	numbers := ClosingIssueNumbers(body)
+
+	if !reflect.DeepEqual(numbers, []int{12, 3, 7}) {
+		t.Errorf("Unexpected closing issue numbers: %v", numbers)
+	}
+}
+
+func TestLinkedIssueNumbers(t *testing.T) {
+	ref := "7-some-awesome-feature"
+	body := "Fixes #3 and fixes #7"
+
+	numbers := LinkedIssueNumbers(&github.PullRequest{Body: &body, Head: &github.PullRequestBranch{Ref: &ref}})
+
+	if !reflect.DeepEqual(numbers, []int{3, 7}) {
+		t.Errorf("Unexpected linked issue numbers: %v", numbers)
+	}
+}
+
+// relationshipDatabase is a database, which only contains relationships. It supports the queries
+// used for blocking issues
+type relationshipDatabase struct {
+	Database
+	relationships []*Relationship
+}
+
+func (db *relationshipDatabase) GetRelationships(query interface{}, args ...interface{}) (result []*Relationship, err error) {
+	for _, r := range db.relationships {
+		var fields []interface{}
+
+		switch query {
+		case "repository_id = ? AND issue_id = ? AND type = ?":
+			fields = []interface{}{r.RepositoryID, r.IssueID, r.Type}
+		case "repository_id = ? AND other_issue_id = ? AND type = ?":
+			fields = []interface{}{r.RepositoryID, r.OtherIssueID, r.Type}
+		default:
+			return nil, fmt.Errorf("unsupported query %v", query)
+		}
+
+		if fmt.Sprint(fields...) == fmt.Sprint(args...) {
+			result = append(result, r)
+		}
+	}
+
+	return result, nil
+}
+
+func TestUpdateBlockerStatus(t *testing.T) {
+	var states []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/aybaze/frontend/issues/7":
+			fmt.Fprint(w, `{"number": 7, "state": "open"}`)
+		case "/repos/aybaze/frontend/statuses/abc":
+			var status github.RepoStatus
+			json.NewDecoder(r.Body).Decode(&status)
+
+			states = append(states, status.GetState())
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	app := &Application{db: &relationshipDatabase{relationships: []*Relationship{
+		// #5 of the api is blocked by #7 of the api, not of the frontend
+		{RepositoryID: 1, IssueID: 5, OtherIssueID: 7, Type: RelationshipBlockedBy},
+		{RepositoryID: 2, IssueID: 6, OtherIssueID: 7, Type: RelationshipBlockedBy},
+	}}}
+
+	id, owner, name, sha := int64(2), "aybaze", "frontend", "abc"
+	repo := &github.Repository{ID: &id, Owner: &github.User{Login: &owner}, Name: &name}
+
+	for _, body := range []string{"Fixes #5", "Fixes #6"} {
+		pull := &github.PullRequest{Body: &body, Head: &github.PullRequestBranch{SHA: &sha}}
+
+		if err := app.UpdateBlockerStatus(&GitHubClients{V3: client}, repo, pull); err != nil {
+			t.Fatalf("Could not update blocker status: %s", err)
+		}
+	}
+
+	if !reflect.DeepEqual(states, []string{"success", "failure"}) {
+		t.Errorf("Expected only relationships of the repository to block, got states %v", states)
+	}
+}
@@ -0,0 +1,381 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// Sort fields supported by the sort: qualifier of a query
+const (
+	SortRank      = "rank"
+	SortCreated   = "created"
+	SortUpdated   = "updated"
+	SortReactions = "reactions"
+)
+
+// AssigneeMe is replaced by the login of the current user when a query is applied
+const AssigneeMe = "@me"
+
+// AssigneeNone matches issues without any assignee
+const AssigneeNone = "none"
+
+// FieldNone matches issues without a value for a custom field
+const FieldNone = "none"
+
+// BacklogQuery is a parsed query to filter and sort the issues of a workspace. A query consists of
+// qualifiers such as label:bug, assignee:@me or repo:api, which can be negated with a leading
+// '-', a sort:<field>-<asc|desc> qualifier and free text that needs to be contained in the title.
+// All other qualifiers filter by the custom fields of the workspace, e.g. severity:high
+type BacklogQuery struct {
+	Labels               []string `json:"labels,omitempty"`
+	ExcludedLabels       []string `json:"excludedLabels,omitempty"`
+	Assignees            []string `json:"assignees,omitempty"`
+	ExcludedAssignees    []string `json:"excludedAssignees,omitempty"`
+	Repositories         []string `json:"repositories,omitempty"`
+	ExcludedRepositories []string `json:"excludedRepositories,omitempty"`
+	Text                 []string `json:"text,omitempty"`
+
+	Fields         map[string][]string `json:"fields,omitempty"`
+	ExcludedFields map[string][]string `json:"excludedFields,omitempty"`
+
+	SortField      string `json:"sortField"`
+	SortDescending bool   `json:"sortDescending"`
+}
+
+// ParseBacklogQuery parses the query string. Values containing spaces can be quoted,
+// e.g. label:"good first issue"
+func ParseBacklogQuery(query string) (q *BacklogQuery, err error) {
+	var tokens []string
+
+	if tokens, err = tokenizeQuery(query); err != nil {
+		return nil, err
+	}
+
+	q = &BacklogQuery{SortField: SortRank}
+
+	for _, token := range tokens {
+		negated := strings.HasPrefix(token, "-")
+		if negated {
+			token = token[1:]
+		}
+
+		parts := strings.SplitN(token, ":", 2)
+		if len(parts) != 2 {
+			if negated {
+				return nil, fmt.Errorf("%w: free text '%s' cannot be negated", ErrValidationFailed, token)
+			}
+
+			q.Text = append(q.Text, strings.ToLower(token))
+			continue
+		}
+
+		key, value := strings.ToLower(parts[0]), parts[1]
+		if value == "" {
+			return nil, fmt.Errorf("%w: qualifier %s needs a value", ErrValidationFailed, key)
+		}
+
+		switch key {
+		case "label":
+			q.Labels, q.ExcludedLabels = appendQualifier(q.Labels, q.ExcludedLabels, value, negated)
+		case "assignee":
+			q.Assignees, q.ExcludedAssignees = appendQualifier(q.Assignees, q.ExcludedAssignees, value, negated)
+		case "repo":
+			q.Repositories, q.ExcludedRepositories = appendQualifier(q.Repositories, q.ExcludedRepositories, value, negated)
+		case "sort":
+			if negated {
+				return nil, fmt.Errorf("%w: sort cannot be negated", ErrValidationFailed)
+			}
+
+			if err = q.parseSort(value); err != nil {
+				return nil, err
+			}
+		default:
+			q.addField(key, value, negated)
+		}
+	}
+
+	return q, nil
+}
+
+func appendQualifier(included []string, excluded []string, value string, negated bool) ([]string, []string) {
+	if negated {
+		return included, append(excluded, value)
+	}
+
+	return append(included, value), excluded
+}
+
+// addField adds a filter for a custom field. Whether the field exists, can only be checked
+// against the workspace, see ValidateBacklogQuery
+func (q *BacklogQuery) addField(name string, value string, negated bool) {
+	if negated {
+		if q.ExcludedFields == nil {
+			q.ExcludedFields = make(map[string][]string)
+		}
+
+		q.ExcludedFields[name] = append(q.ExcludedFields[name], value)
+		return
+	}
+
+	if q.Fields == nil {
+		q.Fields = make(map[string][]string)
+	}
+
+	q.Fields[name] = append(q.Fields[name], value)
+}
+
+func (q *BacklogQuery) parseSort(value string) error {
+	parts := strings.SplitN(strings.ToLower(value), "-", 2)
+
+	switch parts[0] {
+	case SortRank, SortCreated, SortUpdated, SortReactions:
+		q.SortField = parts[0]
+	default:
+		return fmt.Errorf("%w: unknown sort field %s", ErrValidationFailed, parts[0])
+	}
+
+	// the rank is naturally ascending, everything else is sorted by the newest or most first
+	q.SortDescending = q.SortField != SortRank
+
+	if len(parts) == 2 {
+		switch parts[1] {
+		case "asc":
+			q.SortDescending = false
+		case "desc":
+			q.SortDescending = true
+		default:
+			return fmt.Errorf("%w: unknown sort direction %s", ErrValidationFailed, parts[1])
+		}
+	}
+
+	return nil
+}
+
+// tokenizeQuery splits the query at white spaces, unless they are quoted
+func tokenizeQuery(query string) (tokens []string, err error) {
+	var (
+		token    strings.Builder
+		inQuotes bool
+	)
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case (r == ' ' || r == '\t' || r == '\n') && !inQuotes:
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+		default:
+			token.WriteRune(r)
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("%w: unterminated quote in query", ErrValidationFailed)
+	}
+
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+
+	return
+}
+
+// Apply returns all issues matching the query in the requested order. The login of the current
+// user is needed to resolve @me
+func (q *BacklogQuery) Apply(issues []*BacklogIssue, login string) (result []*BacklogIssue) {
+	result = []*BacklogIssue{}
+
+	for _, issue := range issues {
+		if q.Matches(issue, login) {
+			result = append(result, issue)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return q.Less(result[i], result[j])
+	})
+
+	return
+}
+
+// Less checks, whether issue a is sorted before issue b by the query
+func (q *BacklogQuery) Less(a *BacklogIssue, b *BacklogIssue) bool {
+	if q.SortDescending {
+		a, b = b, a
+	}
+
+	switch q.SortField {
+	case SortCreated:
+		return a.CreatedAt.Before(b.CreatedAt)
+	case SortUpdated:
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	case SortReactions:
+		return a.Reactions < b.Reactions
+	default:
+		return a.Rank < b.Rank
+	}
+}
+
+// Streamable checks, whether the issues of the query can be retrieved in their order from GitHub,
+// so that they can be streamed instead of being sorted as a whole. This is only the case for
+// sorting by creation or update, the rank and the reactions are not known to GitHub's ordering
+func (q *BacklogQuery) Streamable() bool {
+	return q.SortField == SortCreated || q.SortField == SortUpdated
+}
+
+// issueOrder returns the order of issues retrieved from GitHub for a streamable query
+func (q *BacklogQuery) issueOrder() *githubv4.IssueOrder {
+	order := &githubv4.IssueOrder{Field: githubv4.IssueOrderFieldCreatedAt, Direction: githubv4.OrderDirectionAsc}
+
+	if q.SortField == SortUpdated {
+		order.Field = githubv4.IssueOrderFieldUpdatedAt
+	}
+
+	if q.SortDescending {
+		order.Direction = githubv4.OrderDirectionDesc
+	}
+
+	return order
+}
+
+// Matches checks, whether the issue matches all filters of the query
+func (q *BacklogQuery) Matches(issue *BacklogIssue, login string) bool {
+	for _, label := range q.Labels {
+		if !containsFold(issue.Labels, label) {
+			return false
+		}
+	}
+
+	for _, label := range q.ExcludedLabels {
+		if containsFold(issue.Labels, label) {
+			return false
+		}
+	}
+
+	for _, assignee := range q.Assignees {
+		if !matchesAssignee(issue, assignee, login) {
+			return false
+		}
+	}
+
+	for _, assignee := range q.ExcludedAssignees {
+		if matchesAssignee(issue, assignee, login) {
+			return false
+		}
+	}
+
+	if len(q.Repositories) > 0 {
+		var found bool
+		for _, repo := range q.Repositories {
+			found = found || matchesRepository(issue, repo)
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	for _, repo := range q.ExcludedRepositories {
+		if matchesRepository(issue, repo) {
+			return false
+		}
+	}
+
+	// an issue needs to have one of the values of each field
+	for name, values := range q.Fields {
+		var found bool
+		for _, value := range values {
+			found = found || matchesField(issue, name, value)
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	for name, values := range q.ExcludedFields {
+		for _, value := range values {
+			if matchesField(issue, name, value) {
+				return false
+			}
+		}
+	}
+
+	title := strings.ToLower(issue.Title)
+	for _, text := range q.Text {
+		if !strings.Contains(title, text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesAssignee(issue *BacklogIssue, assignee string, login string) bool {
+	switch assignee {
+	case AssigneeMe:
+		return containsFold(issue.Assignees, login)
+	case AssigneeNone:
+		return len(issue.Assignees) == 0
+	default:
+		return containsFold(issue.Assignees, assignee)
+	}
+}
+
+func matchesField(issue *BacklogIssue, name string, value string) bool {
+	var actual string
+
+	for field, v := range issue.Fields {
+		if strings.EqualFold(field, name) {
+			actual = v
+			break
+		}
+	}
+
+	if strings.EqualFold(value, FieldNone) {
+		return actual == ""
+	}
+
+	return actual != "" && strings.EqualFold(actual, value)
+}
+
+// matchesRepository checks the repository either by its full name or just its name
+func matchesRepository(issue *BacklogIssue, repo string) bool {
+	if strings.Contains(repo, "/") {
+		return strings.EqualFold(issue.Repository, repo)
+	}
+
+	parts := strings.SplitN(issue.Repository, "/", 2)
+
+	return strings.EqualFold(parts[len(parts)-1], repo)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package issues
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBacklogQuery(t *testing.T) {
+	q, err := ParseBacklogQuery(`label:bug assignee:@me repo:api -label:wontfix label:"good first issue" crash sort:updated-desc`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := &BacklogQuery{
+		Labels:         []string{"bug", "good first issue"},
+		ExcludedLabels: []string{"wontfix"},
+		Assignees:      []string{AssigneeMe},
+		Repositories:   []string{"api"},
+		Text:           []string{"crash"},
+		SortField:      SortUpdated,
+		SortDescending: true,
+	}
+
+	if !reflect.DeepEqual(q, expected) {
+		t.Errorf("Unexpected query %+v", q)
+	}
+
+	if q, _ = ParseBacklogQuery(""); q.SortField != SortRank || q.SortDescending {
+		t.Errorf("Expected default sort by ascending rank, got %+v", q)
+	}
+
+	if q, _ = ParseBacklogQuery("severity:high -customer:acme severity:critical"); !reflect.DeepEqual(q.Fields, map[string][]string{"severity": {"high", "critical"}}) || !reflect.DeepEqual(q.ExcludedFields, map[string][]string{"customer": {"acme"}}) {
+		t.Errorf("Unexpected field filters %+v", q)
+	}
+
+	for _, invalid := range []string{"label:", "sort:title", "sort:created-up", "-sort:created", `label:"bug`, "-text"} {
+		if _, err = ParseBacklogQuery(invalid); err == nil {
+			t.Errorf("Expected error for query %s", invalid)
+		}
+	}
+}
+
+func TestBacklogQueryApply(t *testing.T) {
+	now := time.Now()
+	issues := []*BacklogIssue{
+		{Number: 1, Repository: "aybaze/api", Title: "Crash on start", Labels: []string{"Bug"}, Assignees: []string{"oxisto"}, Rank: "a", UpdatedAt: now.Add(-time.Hour)},
+		{Number: 2, Repository: "aybaze/api", Title: "Crash on exit", Labels: []string{"bug", "wontfix"}, Assignees: []string{"oxisto"}, Rank: "b", UpdatedAt: now},
+		{Number: 3, Repository: "aybaze/frontend", Title: "Crash in menu", Labels: []string{"bug"}, Assignees: []string{}, Rank: "c", UpdatedAt: now},
+		{Number: 4, Repository: "aybaze/api", Title: "Another crash", Labels: []string{"bug"}, Assignees: []string{"oxisto"}, Rank: "d", UpdatedAt: now.Add(time.Hour)},
+	}
+
+	q, _ := ParseBacklogQuery("label:bug assignee:@me repo:api -label:wontfix crash sort:updated-desc")
+
+	var numbers []int
+	for _, issue := range q.Apply(issues, "oxisto") {
+		numbers = append(numbers, issue.Number)
+	}
+
+	if !reflect.DeepEqual(numbers, []int{4, 1}) {
+		t.Errorf("Unexpected result %v", numbers)
+	}
+
+	issues[0].Fields = map[string]string{"Severity": "high"}
+	issues[2].Fields = map[string]string{"Severity": "low"}
+
+	q, _ = ParseBacklogQuery("severity:HIGH severity:none -severity:low")
+
+	numbers = nil
+	for _, issue := range q.Apply(issues, "oxisto") {
+		numbers = append(numbers, issue.Number)
+	}
+
+	if !reflect.DeepEqual(numbers, []int{1, 2, 4}) {
+		t.Errorf("Unexpected result %v", numbers)
+	}
+}
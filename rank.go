@@ -0,0 +1,194 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// rankAlphabet contains the digits of a rank in lexicographic order
+const rankAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// BacklogRank is the position of an issue within the backlog of a workspace. Ranks are compared
+// lexicographically, so that an issue can be moved by only updating its own rank
+type BacklogRank struct {
+	WorkspaceID int64  `json:"workspaceID" gorm:"primary_key;auto_increment:false"`
+	IssueID     string `json:"issueID" gorm:"primary_key"`
+	Rank        string `json:"rank"`
+}
+
+// RankBetween returns a rank that is sorted between prev and next. An empty prev denotes the
+// beginning of the backlog, an empty next the end of it. There is no rank between ranks, which only
+// differ by trailing zeros, e.g. a and a0. We never generate such ranks, but they can be imported
+func RankBetween(prev string, next string) (string, error) {
+	if next != "" && prev >= next {
+		return "", fmt.Errorf("%w: rank %s needs to be before rank %s", ErrValidationFailed, prev, next)
+	}
+
+	rank, err := rankBetween(prev, next)
+	if err != nil {
+		return "", err
+	}
+
+	if next != "" && rank >= next {
+		return "", fmt.Errorf("%w: there is no rank between %s and %s", ErrValidationFailed, prev, next)
+	}
+
+	return rank, nil
+}
+
+// rankBetween returns the shortest rank after prev, which is before next, if there is one
+func rankBetween(prev string, next string) (string, error) {
+	var rank []byte
+
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(prev) {
+			lo = strings.IndexByte(rankAlphabet, prev[i])
+		}
+
+		hi := len(rankAlphabet)
+		if next != "" && i < len(next) {
+			hi = strings.IndexByte(rankAlphabet, next[i])
+		}
+
+		if lo < 0 || hi < 0 {
+			return "", fmt.Errorf("%w: invalid rank %s or %s", ErrValidationFailed, prev, next)
+		}
+
+		if lo == hi {
+			// common prefix, we need to look further
+			rank = append(rank, rankAlphabet[lo])
+			continue
+		}
+
+		mid := (lo + hi) / 2
+		if mid > lo {
+			return string(append(rank, rankAlphabet[mid])), nil
+		}
+
+		// the digits are adjacent, so anything after prev's digit is before next
+		rank = append(rank, rankAlphabet[lo])
+		next = ""
+	}
+}
+
+// MoveBacklogIssue moves an issue within the backlog of a workspace between the two neighbouring
+// issues. An empty after denotes the top of the backlog, an empty before the bottom. The issue is
+// retrieved using the clients of the user to check, whether it belongs to a workspace repository
+func (app *Application) MoveBacklogIssue(clients *GitHubClients, workspace *Workspace, issueID string, after string, before string) (rank *BacklogRank, err error) {
+	var (
+		issues []*BacklogIssue
+		ranks  []*BacklogRank
+		prev   string
+		next   string
+	)
+
+	if issueID == "" || issueID == after || issueID == before {
+		return nil, fmt.Errorf("%w: invalid issue to move", ErrValidationFailed)
+	}
+
+	if issues, err = app.GetIssuesByID(clients, []string{issueID}); err != nil {
+		return nil, err
+	}
+
+	if len(issues) == 0 || !workspace.RepositoryIDs.Contains(issues[0].RepositoryID) {
+		return nil, fmt.Errorf("%w: issue %s is not part of a repository of workspace %d", ErrValidationFailed, issueID, workspace.ID)
+	}
+
+	if ranks, err = app.db.GetBacklogRanks("workspace_id = ? AND issue_id IN (?)", workspace.ID, []string{after, before}); err != nil {
+		return nil, fmt.Errorf("Could not fetch backlog ranks from database: %w", err)
+	}
+
+	for _, r := range ranks {
+		if r.IssueID == after {
+			prev = r.Rank
+		} else if r.IssueID == before {
+			next = r.Rank
+		}
+	}
+
+	if (after != "" && prev == "") || (before != "" && next == "") {
+		return nil, fmt.Errorf("%w: neighbouring issues are not part of the backlog", ErrValidationFailed)
+	}
+
+	rank = &BacklogRank{WorkspaceID: workspace.ID, IssueID: issueID}
+
+	if rank.Rank, err = RankBetween(prev, next); err != nil {
+		return nil, err
+	}
+
+	// Update either inserts or updates the rank
+	if _, err = app.db.Update(rank); err != nil {
+		return nil, err
+	}
+
+	return rank, nil
+}
+
+// rankBacklog sorts the issues of the backlog according to their rank. Issues without a rank
+// are appended to the bottom in the order of their creation and get a rank assigned
+func (app *Application) rankBacklog(backlog *Backlog) (err error) {
+	var (
+		ranks    []*BacklogRank
+		last     string
+		unranked []*BacklogIssue
+		ranked   []*BacklogIssue
+	)
+
+	if ranks, err = app.db.GetBacklogRanks("workspace_id = ?", backlog.WorkspaceID); err != nil {
+		return fmt.Errorf("Could not fetch backlog ranks from database: %w", err)
+	}
+
+	byIssue := make(map[string]string)
+	for _, r := range ranks {
+		byIssue[r.IssueID] = r.Rank
+
+		if r.Rank > last {
+			last = r.Rank
+		}
+	}
+
+	for _, issue := range backlog.Issues {
+		if issue.Rank = byIssue[issue.ID]; issue.Rank != "" {
+			ranked = append(ranked, issue)
+		} else {
+			unranked = append(unranked, issue)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Rank < ranked[j].Rank
+	})
+
+	for _, issue := range unranked {
+		if issue.Rank, err = RankBetween(last, ""); err != nil {
+			return err
+		}
+
+		last = issue.Rank
+
+		if err = app.db.Insert(&BacklogRank{WorkspaceID: backlog.WorkspaceID, IssueID: issue.ID, Rank: issue.Rank}); err != nil {
+			// a concurrent request might have ranked the issue already, it will be sorted correctly next time
+			log.Warnf("Could not store rank of issue %s: %s", issue.ID, err)
+		}
+	}
+
+	backlog.Issues = append(append([]*BacklogIssue{}, ranked...), unranked...)
+
+	return nil
+}
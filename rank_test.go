@@ -0,0 +1,43 @@
+package issues
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	var tests = []struct {
+		prev string
+		next string
+	}{
+		{"", ""},
+		{"", "i"},
+		{"i", ""},
+		{"a", "b"},
+		{"a", "a1"},
+		{"", "01"},
+		{"zz", ""},
+		{"a8", "a9"},
+	}
+
+	for _, test := range tests {
+		rank, err := RankBetween(test.prev, test.next)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q and %q: %s", test.prev, test.next, err)
+		}
+
+		if rank <= test.prev || (test.next != "" && rank >= test.next) || rank[len(rank)-1] == '0' {
+			t.Errorf("Invalid rank %q between %q and %q", rank, test.prev, test.next)
+		}
+	}
+
+	if _, err := RankBetween("b", "a"); err == nil {
+		t.Errorf("Expected error for reversed ranks")
+	}
+
+	for _, next := range []string{"a0", "a00"} {
+		if rank, err := RankBetween("a", next); !errors.Is(err, ErrValidationFailed) {
+			t.Errorf("Expected validation error without rank between a and %s, got rank %q and error %v", next, rank, err)
+		}
+	}
+}
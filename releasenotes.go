@@ -0,0 +1,505 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// ReleaseNotesCategory is a section of the release notes, which contains all items with one of its labels
+type ReleaseNotesCategory struct {
+	Title  string
+	Labels []string
+}
+
+// ReleaseNotesCategories are the sections of release notes in the order they are rendered. An item
+// belongs to the first category matching one of its labels
+var ReleaseNotesCategories = []*ReleaseNotesCategory{
+	{Title: "Breaking Changes", Labels: []string{"breaking", "breaking change"}},
+	{Title: "Features", Labels: []string{"feature", "enhancement"}},
+	{Title: "Fixes", Labels: []string{"bug", "fix"}},
+}
+
+// ReleaseNotesOther is the title of the section containing all items without a category
+const ReleaseNotesOther = "Other Changes"
+
+// ReleaseNoteItem is a closed issue or a merged pull request of a milestone
+type ReleaseNoteItem struct {
+	Repository  string   `json:"repository"`
+	Number      int      `json:"number"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	PullRequest bool     `json:"pullRequest"`
+	Labels      []string `json:"labels"`
+}
+
+// ReleaseNotesSection contains the items of one category
+type ReleaseNotesSection struct {
+	Title string             `json:"title"`
+	Items []*ReleaseNoteItem `json:"items"`
+}
+
+// ReleaseNotes contains the items of a milestone grouped by category and rendered as Markdown
+type ReleaseNotes struct {
+	Milestone string                 `json:"milestone"`
+	Sections  []*ReleaseNotesSection `json:"sections"`
+	Markdown  string                 `json:"markdown"`
+}
+
+// BuildReleaseNotes groups the items by category. Empty sections are omitted
+func BuildReleaseNotes(milestone string, items []*ReleaseNoteItem) *ReleaseNotes {
+	notes := &ReleaseNotes{Milestone: milestone, Sections: []*ReleaseNotesSection{}}
+
+	sorted := append([]*ReleaseNoteItem{}, items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Repository != sorted[j].Repository {
+			return sorted[i].Repository < sorted[j].Repository
+		}
+
+		return sorted[i].Number < sorted[j].Number
+	})
+
+	sections := make(map[string]*ReleaseNotesSection)
+	var titles []string
+
+	for _, category := range ReleaseNotesCategories {
+		titles = append(titles, category.Title)
+	}
+
+	titles = append(titles, ReleaseNotesOther)
+
+	for _, item := range sorted {
+		title := releaseNotesCategory(item)
+
+		if sections[title] == nil {
+			sections[title] = &ReleaseNotesSection{Title: title}
+		}
+
+		sections[title].Items = append(sections[title].Items, item)
+	}
+
+	for _, title := range titles {
+		if sections[title] != nil {
+			notes.Sections = append(notes.Sections, sections[title])
+		}
+	}
+
+	notes.Markdown = RenderReleaseNotes(notes)
+
+	return notes
+}
+
+func releaseNotesCategory(item *ReleaseNoteItem) string {
+	for _, category := range ReleaseNotesCategories {
+		for _, label := range category.Labels {
+			if containsFold(item.Labels, label) {
+				return category.Title
+			}
+		}
+	}
+
+	return ReleaseNotesOther
+}
+
+// RenderReleaseNotes renders the release notes as Markdown. Items are referenced including their
+// repository, so that GitHub links them across repositories
+func RenderReleaseNotes(notes *ReleaseNotes) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n", notes.Milestone)
+
+	if len(notes.Sections) == 0 {
+		b.WriteString("\nNo changes.\n")
+	}
+
+	for _, section := range notes.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", section.Title)
+
+		for _, item := range section.Items {
+			fmt.Fprintf(&b, "- %s (%s#%d)\n", item.Title, item.Repository, item.Number)
+		}
+	}
+
+	return b.String()
+}
+
+// GetReleaseNotes collects the closed issues and merged pull requests of the workspace milestone
+// across all repositories of the workspace
+func (app *Application) GetReleaseNotes(clients *GitHubClients, workspace *Workspace, milestone *WorkspaceMilestone) (notes *ReleaseNotes, err error) {
+	var repositories []*github.Repository
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return nil, err
+	}
+
+	return app.getReleaseNotes(clients, repositories, milestone.Title)
+}
+
+// getReleaseNotes collects the closed issues and merged pull requests of the milestone with the
+// specified title across the repositories. Each repository is queried separately using the number
+// of its milestone, repositories without the milestone are skipped
+func (app *Application) getReleaseNotes(clients *GitHubClients, repositories []*github.Repository, title string) (notes *ReleaseNotes, err error) {
+	var (
+		milestone    *github.Milestone
+		issues       []*BacklogIssue
+		pullRequests []*ReleaseNoteItem
+		items        []*ReleaseNoteItem
+	)
+
+	for _, repo := range repositories {
+		if milestone, err = FindRepositoryMilestone(clients, repo.GetOwner().GetLogin(), repo.GetName(), title); err != nil {
+			return nil, err
+		}
+
+		if milestone == nil {
+			continue
+		}
+
+		filter := githubv4.IssueFilters{
+			Milestone: githubv4.NewString(githubv4.String(strconv.Itoa(milestone.GetNumber()))),
+			States:    &[]githubv4.IssueState{githubv4.IssueStateClosed},
+		}
+
+		if issues, err = app.queryIssues(clients, repo, filter); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			items = append(items, &ReleaseNoteItem{
+				Repository: repo.GetFullName(),
+				Number:     issue.Number,
+				Title:      issue.Title,
+				URL:        issue.URL,
+				Labels:     issue.Labels,
+			})
+		}
+
+		if pullRequests, err = queryMergedPullRequests(clients, repo, milestone.GetNumber()); err != nil {
+			return nil, err
+		}
+
+		items = append(items, pullRequests...)
+	}
+
+	return BuildReleaseNotes(title, items), nil
+}
+
+// queryMergedPullRequests retrieves the merged pull requests of the milestone with the specified
+// number in the repository using the GraphQL API
+func queryMergedPullRequests(clients *GitHubClients, repo *github.Repository, number int) (items []*ReleaseNoteItem, err error) {
+	var q struct {
+		Repository struct {
+			Milestone struct {
+				PullRequests struct {
+					Nodes []struct {
+						Number int
+						Title  string
+						URL    string
+						Labels struct {
+							Nodes []struct {
+								Name string
+							}
+						} `graphql:"labels(first: 20)"`
+					}
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"pullRequests(first: 100, after: $cursor, states: MERGED)"`
+			} `graphql:"milestone(number: $milestoneNumber)"`
+		} `graphql:"repository(owner: $repositoryOwner, name: $repositoryName)"`
+	}
+
+	variables := map[string]interface{}{
+		"repositoryOwner": githubv4.String(repo.GetOwner().GetLogin()),
+		"repositoryName":  githubv4.String(repo.GetName()),
+		"milestoneNumber": githubv4.Int(number),
+		"cursor":          (*githubv4.String)(nil),
+	}
+
+	for {
+		if err = clients.V4.Query(context.Background(), &q, variables); err != nil {
+			return nil, fmt.Errorf("Could not query pull requests of milestone %d of %s: %w", number, repo.GetFullName(), err)
+		}
+
+		for _, node := range q.Repository.Milestone.PullRequests.Nodes {
+			item := &ReleaseNoteItem{
+				Repository:  repo.GetFullName(),
+				Number:      node.Number,
+				Title:       node.Title,
+				URL:         node.URL,
+				PullRequest: true,
+				Labels:      []string{},
+			}
+
+			for _, label := range node.Labels.Nodes {
+				item.Labels = append(item.Labels, label.Name)
+			}
+
+			items = append(items, item)
+		}
+
+		if !q.Repository.Milestone.PullRequests.PageInfo.HasNextPage {
+			return items, nil
+		}
+
+		variables["cursor"] = githubv4.NewString(q.Repository.Milestone.PullRequests.PageInfo.EndCursor)
+	}
+}
+
+// releaseNotesRepositories returns the repositories, whose items can be published in a comment in
+// the target repository on behalf of the user with the specified login. The user needs to be able
+// to read each of them and items of private repositories are never published in public ones
+func releaseNotesRepositories(clients *GitHubClients, repositories []*github.Repository, target *github.Repository, login string) (allowed []*github.Repository, err error) {
+	var readable bool
+
+	for _, repo := range repositories {
+		if repo.GetPrivate() && !target.GetPrivate() {
+			continue
+		}
+
+		if readable, err = HasRepositoryPermission(clients, repo, login, PermissionRead); err != nil {
+			return nil, err
+		}
+
+		if readable {
+			allowed = append(allowed, repo)
+		}
+	}
+
+	return allowed, nil
+}
+
+// releaseTagInvalid matches all characters, which we do not use in tag names
+var releaseTagInvalid = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// releaseTagDots matches consecutive dots, which are not allowed in tag names
+var releaseTagDots = regexp.MustCompile(`\.\.+`)
+
+// ReleaseTagName derives a valid git tag name from the title of a milestone, e.g. Sprint 3 becomes
+// Sprint-3. Titles without any usable character are rejected
+func ReleaseTagName(title string) (string, error) {
+	tag := releaseTagInvalid.ReplaceAllString(title, "-")
+	tag = releaseTagDots.ReplaceAllString(tag, ".")
+	tag = strings.Trim(strings.TrimSuffix(strings.Trim(tag, "-."), ".lock"), "-.")
+
+	if tag == "" {
+		return "", fmt.Errorf("%w: no tag name can be derived from milestone %s", ErrValidationFailed, title)
+	}
+
+	return tag, nil
+}
+
+// CreateDraftReleases creates a draft release named after the milestone in each repository of
+// the workspace, which contains items of the release notes. Each release only contains the items
+// of its repository and is tagged with the tag name derived from the milestone title. Existing
+// drafts of the milestone are updated instead, repositories which already published a release of
+// the milestone are skipped
+func (app *Application) CreateDraftReleases(clients *GitHubClients, workspace *Workspace, notes *ReleaseNotes) (releases []*github.RepositoryRelease, err error) {
+	var (
+		repositories []*github.Repository
+		release      *github.RepositoryRelease
+		existing     *github.RepositoryRelease
+		tag          string
+	)
+
+	if tag, err = ReleaseTagName(notes.Milestone); err != nil {
+		return nil, err
+	}
+
+	if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+		return nil, err
+	}
+
+	releases = []*github.RepositoryRelease{}
+
+	for _, repo := range repositories {
+		var items []*ReleaseNoteItem
+
+		for _, section := range notes.Sections {
+			for _, item := range section.Items {
+				if strings.EqualFold(item.Repository, repo.GetFullName()) {
+					items = append(items, item)
+				}
+			}
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+
+		draft := true
+		body := BuildReleaseNotes(notes.Milestone, items).Markdown
+		request := &github.RepositoryRelease{
+			TagName: &tag,
+			Name:    &notes.Milestone,
+			Body:    &body,
+			Draft:   &draft,
+		}
+
+		if existing, err = findRelease(clients, repo, tag); err != nil {
+			return nil, err
+		}
+
+		if existing != nil && !existing.GetDraft() {
+			log.Infof("Release %s in %s is already published, not creating a draft", tag, repo.GetFullName())
+			continue
+		}
+
+		if existing != nil {
+			if release, _, err = clients.V3.Repositories.EditRelease(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), existing.GetID(), request); err != nil {
+				return nil, fmt.Errorf("Could not update draft release in %s: %w", repo.GetFullName(), err)
+			}
+
+			log.Infof("Updated draft release %s in %s", tag, repo.GetFullName())
+		} else {
+			if release, _, err = clients.V3.Repositories.CreateRelease(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), request); err != nil {
+				return nil, fmt.Errorf("Could not create draft release in %s: %w", repo.GetFullName(), err)
+			}
+
+			log.Infof("Created draft release %s in %s", tag, repo.GetFullName())
+		}
+
+		releases = append(releases, release)
+	}
+
+	return releases, nil
+}
+
+// canWriteRepositories checks, whether the user with the specified login has write permission in
+// all repositories
+func (app *Application) canWriteRepositories(clients *GitHubClients, repositoryIDs []int64, login string) (allowed bool, err error) {
+	var repositories []*github.Repository
+
+	if repositories, err = app.GetRepositories(clients, repositoryIDs); err != nil {
+		return false, err
+	}
+
+	for _, repo := range repositories {
+		if allowed, err = HasRepositoryPermission(clients, repo, login, PermissionWrite); err != nil || !allowed {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// findRelease returns the release of the repository with the specified tag, including drafts, or
+// nil, if it does not exist. Drafts cannot be retrieved by their tag, so all releases are listed
+func findRelease(clients *GitHubClients, repo *github.Repository, tag string) (*github.RepositoryRelease, error) {
+	options := github.ListOptions{PerPage: 100}
+
+	for {
+		releases, resp, err := clients.V3.Repositories.ListReleases(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), &options)
+		if err != nil {
+			return nil, fmt.Errorf("Could not list releases of %s: %w", repo.GetFullName(), err)
+		}
+
+		for _, release := range releases {
+			if release.GetTagName() == tag {
+				return release, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+// PostReleaseNotes comments the release notes of the milestone of the issue. This is used by the
+// /release-notes command. The notes only contain items of repositories the user with the specified
+// login can read and which are not more private than the repository of the issue. If draft is
+// set, draft releases are created as well in the workspaces, in which the user can write to all
+// repositories
+func (app *Application) PostReleaseNotes(clients *GitHubClients, repo *github.Repository, issue *github.Issue, login string, draft bool) (err error) {
+	var (
+		workspaces   []*Workspace
+		milestone    *WorkspaceMilestone
+		repositories []*github.Repository
+		notes        *ReleaseNotes
+		bodies       []string
+		allowed      bool
+	)
+
+	title := issue.GetMilestone().GetTitle()
+
+	if title != "" {
+		if workspaces, err = app.db.GetWorkspaces("? = ANY(repository_ids)", repo.GetID()); err != nil {
+			return fmt.Errorf("Could not fetch workspaces from database: %w", err)
+		}
+	}
+
+	for _, workspace := range workspaces {
+		if milestone, err = app.GetWorkspaceMilestone(workspace.ID, title); err != nil {
+			return err
+		}
+
+		if milestone == nil {
+			continue
+		}
+
+		if repositories, err = app.GetRepositories(clients, workspace.RepositoryIDs); err != nil {
+			return err
+		}
+
+		// the comment might be visible to more users than the repositories of the workspace
+		if repositories, err = releaseNotesRepositories(clients, repositories, repo, login); err != nil {
+			return err
+		}
+
+		if notes, err = app.getReleaseNotes(clients, repositories, title); err != nil {
+			return err
+		}
+
+		if draft {
+			if allowed, err = app.canWriteRepositories(clients, workspace.RepositoryIDs, login); err != nil {
+				return err
+			}
+
+			if !allowed {
+				log.Infof("Not creating draft releases in workspace %d, since %s cannot write to all of its repositories", workspace.ID, login)
+			} else if _, err = app.CreateDraftReleases(clients, workspace, notes); err != nil {
+				return err
+			}
+		}
+
+		bodies = append(bodies, notes.Markdown)
+	}
+
+	if len(bodies) == 0 {
+		bodies = append(bodies, "This issue does not belong to a milestone of any workspace.")
+	}
+
+	for _, body := range bodies {
+		if _, _, err = clients.V3.Issues.CreateComment(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueComment{
+			Body: &body,
+		}); err != nil {
+			return fmt.Errorf("Creating comment for issue %s failed: %w", GetIssueIdentifier(repo, issue), err)
+		}
+	}
+
+	return nil
+}
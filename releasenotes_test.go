@@ -0,0 +1,131 @@
+package issues
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v29/github"
+)
+
+func TestBuildReleaseNotes(t *testing.T) {
+	items := []*ReleaseNoteItem{
+		{Repository: "aybaze/frontend", Number: 7, Title: "Dark mode", Labels: []string{"Enhancement"}},
+		{Repository: "aybaze/api", Number: 12, Title: "Remove v1 API", Labels: []string{"feature", "breaking"}},
+		{Repository: "aybaze/api", Number: 3, Title: "Fix crash on start", Labels: []string{"bug"}, PullRequest: true},
+		{Repository: "aybaze/api", Number: 9, Title: "Update dependencies", Labels: []string{}},
+		{Repository: "aybaze/api", Number: 4, Title: "Login button", Labels: []string{"feature"}},
+	}
+
+	notes := BuildReleaseNotes("v1.0", items)
+
+	expected := `# v1.0
+
+## Breaking Changes
+
+- Remove v1 API (aybaze/api#12)
+
+## Features
+
+- Login button (aybaze/api#4)
+- Dark mode (aybaze/frontend#7)
+
+## Fixes
+
+- Fix crash on start (aybaze/api#3)
+
+## Other Changes
+
+- Update dependencies (aybaze/api#9)
+`
+
+	if notes.Markdown != expected {
+		t.Errorf("Unexpected release notes:\n%s", notes.Markdown)
+	}
+
+	if notes = BuildReleaseNotes("v1.1", nil); notes.Markdown != "# v1.1\n\nNo changes.\n" {
+		t.Errorf("Unexpected empty release notes:\n%s", notes.Markdown)
+	}
+}
+
+func TestReleaseNotesRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/aybaze/api/collaborators/writer/permission", "/repos/aybaze/internal/collaborators/writer/permission":
+			fmt.Fprint(w, `{"permission": "write"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	clients := &GitHubClients{V3: client}
+
+	repository := func(name string, private bool) *github.Repository {
+		owner, fullName := "aybaze", "aybaze/"+name
+		return &github.Repository{Owner: &github.User{Login: &owner}, Name: &name, FullName: &fullName, Private: &private}
+	}
+
+	api, internal, secret := repository("api", false), repository("internal", true), repository("secret", true)
+	repositories := []*github.Repository{api, internal, secret}
+
+	tests := []struct {
+		target   *github.Repository
+		expected []string
+	}{
+		// private repositories are never published in public ones
+		{api, []string{"aybaze/api"}},
+		// the user cannot read the secret repository
+		{internal, []string{"aybaze/api", "aybaze/internal"}},
+	}
+
+	for _, test := range tests {
+		allowed, err := releaseNotesRepositories(clients, repositories, test.target, "writer")
+		if err != nil {
+			t.Fatalf("Could not filter repositories: %s", err)
+		}
+
+		var names []string
+		for _, repo := range allowed {
+			names = append(names, repo.GetFullName())
+		}
+
+		if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Expected repositories %v for %s, got %v", test.expected, test.target.GetFullName(), names)
+		}
+	}
+}
+
+func TestReleaseTagName(t *testing.T) {
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"v1.0", "v1.0"},
+		{"Sprint 3", "Sprint-3"},
+		{"Q2 release: final?", "Q2-release-final"},
+		{"..release..1.lock", "release.1"},
+	}
+
+	for _, test := range tests {
+		tag, err := ReleaseTagName(test.title)
+		if err != nil {
+			t.Fatalf("Could not derive tag name of %q: %s", test.title, err)
+		}
+
+		if tag != test.expected {
+			t.Errorf("Expected tag name %q for %q, got %q", test.expected, test.title, tag)
+		}
+	}
+
+	if _, err := ReleaseTagName("?!"); !errors.Is(err, ErrValidationFailed) {
+		t.Errorf("Expected validation error for title without usable characters, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"fmt"
+	"issues"
+	"net/http"
+
+	"github.com/oxisto/go-httputil"
+)
+
+// moveRequest moves an issue to another column of a board
+type moveRequest struct {
+	Repository string `json:"repository"`
+	Number     int    `json:"number"`
+	ColumnID   int64  `json:"columnID"`
+}
+
+func (router *Router) handleGetBoard(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		board     *issues.Board
+		format    string
+		columns   []string
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if format, err = formatFromRequest(r, FormatCSV, FormatXLSX); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if format != FormatJSON {
+		if columns = router.exportColumnsFromRequest(w, r, workspace, nil); columns == nil {
+			return
+		}
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if board, err = router.app.GetBoard(clients, workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if format != FormatJSON {
+		if err = issues.WriteBoard(tableResponse(w, format, fmt.Sprintf("board-%d", workspace.ID)), board, columns); err != nil {
+			log.Errorf("Could not write board: %s", err)
+		}
+
+		return
+	}
+
+	httputil.JSONResponse(w, r, board, nil)
+}
+
+func (router *Router) handleGetBoardColumns(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	columns, err := router.app.GetBoardColumns(workspace.ID)
+
+	httputil.JSONResponse(w, r, columns, err)
+}
+
+func (router *Router) handleSetBoardColumns(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		columns   []*issues.BoardColumn
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &columns); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if err = router.app.SetBoardColumns(workspace.ID, columns); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, columns, nil)
+}
+
+func (router *Router) handleMoveBoardIssue(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		request   moveRequest
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.MoveBoardIssue(clients, workspace, request.Repository, request.Number, request.ColumnID); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
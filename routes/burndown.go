@@ -0,0 +1,73 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"issues"
+	"net/http"
+	"time"
+
+	"github.com/oxisto/go-httputil"
+)
+
+func (router *Router) handleGetMilestoneBurndown(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone *issues.WorkspaceMilestone
+		from      time.Time
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if milestone = router.milestoneFromRequest(w, r, workspace); milestone == nil {
+		return
+	}
+
+	// the start of the burndown can optionally be specified
+	if from, err = dateFromRequest(r, "from", time.Time{}); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	burndown, err := router.app.GetMilestoneBurndown(clients, workspace, milestone, from)
+
+	httputil.JSONResponse(w, r, burndown, err)
+}
+
+func (router *Router) handleGetIterationBurndown(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		iteration *issues.Iteration
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if iteration = router.iterationFromRequest(w, r, workspace); iteration == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	burndown, err := router.app.GetIterationBurndown(clients, iteration)
+
+	httputil.JSONResponse(w, r, burndown, err)
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"fmt"
+	"issues"
+	"net/http"
+	"time"
+
+	"github.com/oxisto/go-httputil"
+)
+
+// calendarTokenResponse contains the token of the calendar feed and the path to subscribe to
+type calendarTokenResponse struct {
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+// handleGetCalendar serves the calendar feed of a workspace. It is not protected by a JWT, since
+// calendar clients cannot authenticate, but by the calendar token of the workspace
+func (router *Router) handleGetCalendar(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspaceID int64
+		workspace   *issues.Workspace
+		events      []*issues.CalendarEvent
+		err         error
+	)
+
+	if workspaceID, err = int64FromRequest(r, "workspaceID"); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if workspace, err = router.app.GetCalendarWorkspace(workspaceID, r.URL.Query().Get("token")); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if events, err = router.app.GetCalendarEvents(workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+
+	if err = issues.WriteCalendar(w, workspace.Name, events, time.Now()); err != nil {
+		log.Errorf("Could not write calendar: %s", err)
+	}
+}
+
+func (router *Router) handleRotateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		response  calendarTokenResponse
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if response.Token, err = router.app.RotateCalendarToken(workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	response.Path = fmt.Sprintf("/api/v1/workspaces/%d/calendar.ics?token=%s", workspace.ID, response.Token)
+
+	httputil.JSONResponse(w, r, &response, nil)
+}
+
+func (router *Router) handleRevokeCalendarToken(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = router.app.RevokeCalendarToken(workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"fmt"
+	"issues"
+	"net/http"
+)
+
+// Formats of responses, which can be selected using the format query parameter
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// formatFromRequest returns the requested format of the response, which defaults to JSON. Besides
+// JSON, only the specified formats are supported
+func formatFromRequest(r *http.Request, supported ...string) (string, error) {
+	format := r.URL.Query().Get("format")
+
+	if format == "" || format == FormatJSON {
+		return FormatJSON, nil
+	}
+
+	for _, s := range supported {
+		if format == s {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: unsupported format %s", issues.ErrValidationFailed, format)
+}
+
+// csvResponse sets the headers of a CSV download
+func csvResponse(w http.ResponseWriter, filename string) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+}
+
+// tableResponse sets the headers of a download in the format and returns a table writer for it.
+// The extension of the format is appended to the name of the file
+func tableResponse(w http.ResponseWriter, format string, name string) issues.TableWriter {
+	if format == FormatXLSX {
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".xlsx"))
+
+		return issues.NewXLSXTable(w)
+	}
+
+	csvResponse(w, name+".csv")
+
+	return issues.NewCSVTable(w)
+}
+
+// exportColumnsFromRequest returns the columns of a CSV or XLSX export. They can be specified as
+// comma-separated list in the columns query parameter, otherwise the columns of the view or the
+// default columns are used. If the columns are invalid, an error response is written and nil is returned
+func (router *Router) exportColumnsFromRequest(w http.ResponseWriter, r *http.Request, workspace *issues.Workspace, view *issues.View) []string {
+	var (
+		columns []string
+		err     error
+	)
+
+	if columns, err = issues.ParseBacklogColumns(r.URL.Query().Get("columns")); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if len(columns) == 0 && view != nil {
+		columns = view.Columns
+	}
+
+	if len(columns) == 0 {
+		columns = issues.DefaultCSVColumns
+	}
+
+	if err = router.app.ValidateBacklogColumns(workspace.ID, columns); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	return columns
+}
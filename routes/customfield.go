@@ -0,0 +1,178 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"issues"
+	"net/http"
+
+	"github.com/oxisto/go-httputil"
+)
+
+// fieldValueRequest sets the value of a custom field of an issue
+type fieldValueRequest struct {
+	issueRequest
+	Value string `json:"value"`
+}
+
+// fieldFromRequest retrieves the custom field referenced in the request path. If the field
+// does not exist, an error response is written and nil is returned
+func (router *Router) fieldFromRequest(w http.ResponseWriter, r *http.Request, workspace *issues.Workspace) *issues.CustomField {
+	var (
+		fieldID int64
+		field   *issues.CustomField
+		err     error
+	)
+
+	if fieldID, err = int64FromRequest(r, "fieldID"); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if field, err = router.app.GetCustomField(workspace.ID, fieldID); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if field == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	return field
+}
+
+func (router *Router) handleGetCustomFields(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	fields, err := router.app.GetCustomFields(workspace.ID)
+
+	httputil.JSONResponse(w, r, fields, err)
+}
+
+func (router *Router) handleCreateCustomField(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		field     issues.CustomField
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &field); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if err = router.app.CreateCustomField(workspace.ID, &field); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponseWithStatus(w, r, &field, nil, http.StatusCreated)
+}
+
+func (router *Router) handleUpdateCustomField(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		previous  *issues.CustomField
+		field     issues.CustomField
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if previous = router.fieldFromRequest(w, r, workspace); previous == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &field); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	field.ID, field.WorkspaceID = previous.ID, workspace.ID
+
+	if err = router.app.UpdateCustomField(&field, previous); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, &field, nil)
+}
+
+func (router *Router) handleDeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		field     *issues.CustomField
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if field = router.fieldFromRequest(w, r, workspace); field == nil {
+		return
+	}
+
+	if err = router.app.DeleteCustomField(field); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (router *Router) handleSetCustomFieldValue(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		field     *issues.CustomField
+		request   fieldValueRequest
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if field = router.fieldFromRequest(w, r, workspace); field == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.SetCustomFieldValueByReference(clients, workspace, field, request.Repository, request.Number, request.Value); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"fmt"
+	"issues"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/oxisto/go-httputil"
+)
+
+// estimateRequest sets the estimate of an issue
+type estimateRequest struct {
+	issueRequest
+	Points float64 `json:"points"`
+}
+
+func (router *Router) handleSetEstimate(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		request   estimateRequest
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.SetEstimateByReference(clients, workspace, request.Repository, request.Number, request.Points); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (router *Router) handleGetMilestoneEstimate(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone *issues.WorkspaceMilestone
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if milestone = router.milestoneFromRequest(w, r, workspace); milestone == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	summary, err := router.app.GetMilestoneEstimate(clients, workspace, milestone.Title)
+
+	httputil.JSONResponse(w, r, summary, err)
+}
+
+func (router *Router) handleGetEpicEstimate(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		number    int
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	if number, err = strconv.Atoi(vars["number"]); err != nil {
+		errorResponse(w, r, fmt.Errorf("%w: invalid issue number", issues.ErrValidationFailed))
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	summary, err := router.app.GetEpicEstimate(clients, workspace, fmt.Sprintf("%s/%s", vars["owner"], vars["name"]), number)
+
+	httputil.JSONResponse(w, r, summary, err)
+}
@@ -53,12 +53,29 @@ func (router *Router) handleGitHubCallback(w http.ResponseWriter, r *http.Reques
 		if event.GetAction() == "created" {
 			comment := event.Comment.GetBody()
 
+			router.handleIssueActivity(clients, event.GetRepo(), event.GetIssue(), event.GetSender())
+
 			if strings.HasPrefix(comment, "/branch") {
 				router.handleBranchIssue(clients, event)
 				return
 			} else if strings.HasPrefix(comment, "/pr") {
 				router.handleIssuePR(clients, event)
 				return
+			} else if strings.HasPrefix(comment, "/milestone ") {
+				router.handleIssueMilestone(clients, event)
+				return
+			} else if strings.HasPrefix(comment, "/sprint ") {
+				router.handleIssueSprint(clients, event)
+				return
+			} else if strings.HasPrefix(comment, "/estimate ") {
+				router.handleIssueEstimate(clients, event)
+				return
+			} else if strings.HasPrefix(comment, "/set ") {
+				router.handleIssueSet(clients, event)
+				return
+			} else if strings.HasPrefix(comment, "/release-notes") {
+				router.handleIssueReleaseNotes(clients, event)
+				return
 			}
 		}
 	} else if eventType == "issues" {
@@ -74,6 +91,11 @@ func (router *Router) handleGitHubCallback(w http.ResponseWriter, r *http.Reques
 
 		log.Debugf("Got event %s for issue %s", event.GetAction(), issues.GetIssueIdentifier(event.Repo, event.Issue))
 
+		switch event.GetAction() {
+		case "edited", "reopened", "assigned", "milestoned":
+			router.handleIssueActivity(clients, event.GetRepo(), event.GetIssue(), event.GetSender())
+		}
+
 		if event.GetAction() == "edited" {
 			// do not trigger on bot updates, otherwise we will update forever
 			if event.Sender.GetType() == "Bot" {
@@ -81,6 +103,37 @@ func (router *Router) handleGitHubCallback(w http.ResponseWriter, r *http.Reques
 			}
 
 			router.handleIssueChange(clients, event)
+		} else if event.GetAction() == "labeled" {
+			if err = router.app.WarnWIPLimits(clients, event.GetRepo(), event.GetIssue(), event.GetLabel().GetName()); err != nil {
+				log.Errorf("Could not check WIP limits for %s: %s", issues.GetIssueIdentifier(event.GetRepo(), event.GetIssue()), err)
+			}
+		} else if event.GetAction() == "closed" || event.GetAction() == "reopened" {
+			if err = router.app.UpdateBlockedPullRequests(clients, event.GetRepo(), event.GetIssue()); err != nil {
+				log.Errorf("Could not update pull requests blocked by %s: %s", issues.GetIssueIdentifier(event.GetRepo(), event.GetIssue()), err)
+			}
+		}
+	} else if eventType == "pull_request" {
+		var (
+			event github.PullRequestEvent
+		)
+		decoder.Decode(&event)
+
+		if clients, err = router.app.GetInstallationClients(event.GetInstallation().GetID()); err != nil {
+			log.Errorf("Could not create installation client: %s", err)
+			return
+		}
+
+		log.Debugf("Got event %s for pull request %s/%s#%d", event.GetAction(), event.GetRepo().GetOwner().GetLogin(), event.GetRepo().GetName(), event.GetNumber())
+
+		switch event.GetAction() {
+		case "opened", "edited", "reopened", "synchronize", "ready_for_review":
+			if err = router.app.UpdateBlockerStatus(clients, event.GetRepo(), event.GetPullRequest()); err != nil {
+				log.Errorf("Could not update blocker status: %s", err)
+			}
+		}
+
+		if err = router.app.SyncPullRequest(clients, event); err != nil {
+			log.Errorf("Could not sync issues linked to pull request: %s", err)
 		}
 	} else {
 		log.Warnf("Not handling unknown event type %s", eventType)
@@ -216,7 +269,7 @@ func (router *Router) handleIssueChange(clients *issues.GitHubClients, event git
 	router.app.UpdateEpicStatus(clients, event)
 
 	// find relationships to other issues
-	if relationships, err = router.app.GetDatabase().GetRelationships(int64(issue.GetNumber())); err != nil {
+	if relationships, err = router.app.GetDatabase().GetRelationships("repository_id = ? AND issue_id = ?", event.GetRepo().GetID(), issue.GetNumber()); err != nil {
 		log.Errorf("Could not fetch relationships to other issues from database: %s", err)
 		return
 	}
@@ -247,3 +300,128 @@ func (router *Router) handleIssueChange(clients *issues.GitHubClients, event git
 
 	log.Infof("Updated issue %s", issues.GetIssueIdentifier(event.GetRepo(), event.GetIssue()))
 }
+
+// handleIssueActivity removes the stale label from issues with new activity of users, if the
+// repository is checked for stale issues. Otherwise, the label is not ours
+func (router *Router) handleIssueActivity(clients *issues.GitHubClients, repo *github.Repository, issue *github.Issue, sender *github.User) {
+	// our own comments and labels are no activity
+	if sender.GetType() == "Bot" {
+		return
+	}
+
+	enabled, err := router.app.IsStaleDetectionEnabled(repo.GetID())
+	if err != nil {
+		log.Errorf("Could not check stale issue detection of %s: %s", repo.GetFullName(), err)
+		return
+	}
+
+	if !enabled {
+		return
+	}
+
+	if err = issues.RemoveStaleLabel(clients, repo, issue); err != nil {
+		log.Errorf("%s", err)
+	}
+}
+
+// commandArgument returns the argument of a command, i.e. the rest of the first line of the comment
+func commandArgument(event github.IssueCommentEvent, command string) string {
+	line := strings.SplitN(event.GetComment().GetBody(), "\n", 2)[0]
+
+	return strings.TrimSpace(strings.TrimPrefix(line, command))
+}
+
+// senderHasPermission checks, whether the sender of the comment has at least the required
+// permission in the repository. Commands modifying issues are executed using the installation
+// clients of our app, so we need to make sure that the sender could do the same
+func senderHasPermission(clients *issues.GitHubClients, event github.IssueCommentEvent, required string) bool {
+	allowed, err := issues.HasRepositoryPermission(clients, event.GetRepo(), event.GetSender().GetLogin(), required)
+	if err != nil {
+		log.Errorf("Could not check permission of %s: %s", event.GetSender().GetLogin(), err)
+		return false
+	}
+
+	if !allowed {
+		log.Infof("Ignoring command of %s in %s, since %s permission is required", event.GetSender().GetLogin(), issues.GetIssueIdentifier(event.GetRepo(), event.GetIssue()), required)
+	}
+
+	return allowed
+}
+
+func (router *Router) handleIssueMilestone(clients *issues.GitHubClients, event github.IssueCommentEvent) {
+	if !senderHasPermission(clients, event, issues.PermissionTriage) {
+		return
+	}
+
+	title := commandArgument(event, "/milestone")
+
+	if title == "" {
+		return
+	}
+
+	if err := router.app.AssignMilestone(clients, event.GetRepo(), event.GetIssue(), title); err != nil {
+		log.Errorf("Could not assign milestone: %s", err)
+	}
+}
+
+func (router *Router) handleIssueSprint(clients *issues.GitHubClients, event github.IssueCommentEvent) {
+	if !senderHasPermission(clients, event, issues.PermissionTriage) {
+		return
+	}
+
+	name := commandArgument(event, "/sprint")
+
+	if name == "" {
+		return
+	}
+
+	if err := router.app.AssignIterationByName(clients, event.GetRepo(), event.GetIssue(), name); err != nil {
+		log.Errorf("Could not assign iteration: %s", err)
+	}
+}
+
+func (router *Router) handleIssueEstimate(clients *issues.GitHubClients, event github.IssueCommentEvent) {
+	if !senderHasPermission(clients, event, issues.PermissionTriage) {
+		return
+	}
+
+	points, err := issues.ParsePoints(commandArgument(event, "/estimate"))
+	if err != nil {
+		log.Errorf("Could not parse estimate: %s", err)
+		return
+	}
+
+	if err = router.app.SetEstimate(clients, event.GetRepo(), event.GetIssue(), points); err != nil {
+		log.Errorf("Could not set estimate: %s", err)
+	}
+}
+
+func (router *Router) handleIssueSet(clients *issues.GitHubClients, event github.IssueCommentEvent) {
+	if !senderHasPermission(clients, event, issues.PermissionTriage) {
+		return
+	}
+
+	// the argument has the form field=value
+	parts := strings.SplitN(commandArgument(event, "/set"), "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		log.Errorf("Could not parse /set command of %s", issues.GetIssueIdentifier(event.GetRepo(), event.GetIssue()))
+		return
+	}
+
+	if err := router.app.SetCustomFieldByName(clients, event.GetRepo(), event.GetIssue(), strings.TrimSpace(parts[0]), parts[1]); err != nil {
+		log.Errorf("Could not set custom field: %s", err)
+	}
+}
+
+func (router *Router) handleIssueReleaseNotes(clients *issues.GitHubClients, event github.IssueCommentEvent) {
+	if !senderHasPermission(clients, event, issues.PermissionWrite) {
+		return
+	}
+
+	// /release-notes draft creates draft releases as well
+	draft := commandArgument(event, "/release-notes") == "draft"
+
+	if err := router.app.PostReleaseNotes(clients, event.GetRepo(), event.GetIssue(), event.GetSender().GetLogin(), draft); err != nil {
+		log.Errorf("Could not post release notes: %s", err)
+	}
+}
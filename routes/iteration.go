@@ -0,0 +1,224 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"issues"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/oxisto/go-httputil"
+)
+
+// issueRequest references an issue by its repository and number
+type issueRequest struct {
+	Repository string `json:"repository"`
+	Number     int    `json:"number"`
+}
+
+// iterationFromRequest retrieves the iteration referenced in the request path. If the iteration
+// does not exist, an error response is written and nil is returned
+func (router *Router) iterationFromRequest(w http.ResponseWriter, r *http.Request, workspace *issues.Workspace) *issues.Iteration {
+	var (
+		iterationID int64
+		iteration   *issues.Iteration
+		err         error
+	)
+
+	if iterationID, err = int64FromRequest(r, "iterationID"); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if iteration, err = router.app.GetIteration(workspace.ID, iterationID); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if iteration == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	return iteration
+}
+
+func (router *Router) handleGetIterations(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	iterations, err := router.app.GetIterations(workspace.ID)
+
+	httputil.JSONResponse(w, r, iterations, err)
+}
+
+func (router *Router) handleGetIteration(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		iteration *issues.Iteration
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if iteration = router.iterationFromRequest(w, r, workspace); iteration == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	report, err := router.app.GetIterationReport(clients, iteration)
+
+	httputil.JSONResponse(w, r, report, err)
+}
+
+func (router *Router) handleCreateIteration(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		iteration issues.Iteration
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &iteration); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if err = router.app.CreateIteration(workspace.ID, &iteration); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponseWithStatus(w, r, &iteration, nil, http.StatusCreated)
+}
+
+func (router *Router) handleUpdateIteration(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		iteration *issues.Iteration
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if iteration = router.iterationFromRequest(w, r, workspace); iteration == nil {
+		return
+	}
+
+	id := iteration.ID
+
+	if err = decodeRequest(r, iteration); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	iteration.ID, iteration.WorkspaceID = id, workspace.ID
+
+	if err = router.app.UpdateIteration(iteration); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, iteration, nil)
+}
+
+func (router *Router) handleDeleteIteration(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		iteration *issues.Iteration
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if iteration = router.iterationFromRequest(w, r, workspace); iteration == nil {
+		return
+	}
+
+	if err = router.app.DeleteIteration(iteration); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (router *Router) handleAssignIteration(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		iteration *issues.Iteration
+		request   issueRequest
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if iteration = router.iterationFromRequest(w, r, workspace); iteration == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.AssignIterationByReference(clients, workspace, iteration, request.Repository, request.Number); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (router *Router) handleUnassignIteration(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		iteration *issues.Iteration
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if iteration = router.iterationFromRequest(w, r, workspace); iteration == nil {
+		return
+	}
+
+	if err = router.app.UnassignIteration(iteration, mux.Vars(r)["issueID"]); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"issues"
+	"net/http"
+
+	"github.com/oxisto/go-httputil"
+)
+
+func (router *Router) handleGetLabels(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	labels, err := router.app.GetWorkspaceLabels(workspace.ID)
+
+	httputil.JSONResponse(w, r, labels, err)
+}
+
+func (router *Router) handleSetLabels(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		labels    []*issues.WorkspaceLabel
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &labels); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if err = router.app.SetWorkspaceLabels(workspace.ID, labels); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, labels, nil)
+}
+
+func (router *Router) handleSyncLabels(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	prune := r.URL.Query().Get("prune") == "true"
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	reports, err := router.app.SyncLabels(clients, workspace, prune, dryRun)
+	if err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, reports, nil)
+}
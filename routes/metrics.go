@@ -0,0 +1,168 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"issues"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oxisto/go-httputil"
+)
+
+func (router *Router) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		metrics   *issues.Metrics
+		format    string
+		from      time.Time
+		to        time.Time
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if format, err = formatFromRequest(r, FormatCSV); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	// the end of the window is inclusive, so it lasts until the end of the day
+	if to, err = dateFromRequest(r, "to", time.Now()); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if r.URL.Query().Get("to") != "" {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	if from, err = dateFromRequest(r, "from", to.Add(-issues.MetricsDefaultWindow)); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if metrics, err = router.app.GetMetrics(clients, workspace, from, to); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if format == FormatCSV {
+		// the rows query parameter selects between one row per issue and one per group
+		switch rows := r.URL.Query().Get("rows"); rows {
+		case "", "groups":
+			writeMetricsCSV(w, metrics)
+		case "issues":
+			writeIssueMetricsCSV(w, metrics)
+		default:
+			errorResponse(w, r, fmt.Errorf("%w: unsupported rows %s", issues.ErrValidationFailed, rows))
+		}
+
+		return
+	}
+
+	httputil.JSONResponse(w, r, metrics, nil)
+}
+
+// writeMetricsCSV writes the aggregated metrics as CSV, one row per group
+func writeMetricsCSV(w http.ResponseWriter, metrics *issues.Metrics) {
+	csvResponse(w, fmt.Sprintf("metrics-%d.csv", metrics.WorkspaceID))
+
+	writer := csv.NewWriter(w)
+
+	header := []string{"scope", "group", "issues"}
+	for _, kind := range []string{"leadTime", "cycleTime"} {
+		for _, p := range issues.MetricsPercentiles {
+			header = append(header, fmt.Sprintf("%sP%vHours", kind, p))
+		}
+	}
+
+	writer.Write(header)
+
+	write := func(scope string, summary *issues.MetricsSummary) {
+		row := []string{scope, issues.EscapeCSVCell(summary.Group), strconv.Itoa(summary.Issues)}
+
+		for _, times := range []map[string]float64{summary.LeadTime, summary.CycleTime} {
+			for _, p := range issues.MetricsPercentiles {
+				row = append(row, strconv.FormatFloat(times[fmt.Sprintf("p%v", p)], 'f', 1, 64))
+			}
+		}
+
+		writer.Write(row)
+	}
+
+	write("workspace", metrics.Workspace)
+
+	for _, summary := range metrics.Repositories {
+		write("repository", summary)
+	}
+
+	for _, summary := range metrics.Labels {
+		write("label", summary)
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		log.Errorf("Could not write metrics: %s", err)
+	}
+}
+
+// writeIssueMetricsCSV writes the lead and cycle time of each issue as CSV
+func writeIssueMetricsCSV(w http.ResponseWriter, metrics *issues.Metrics) {
+	csvResponse(w, fmt.Sprintf("metrics-issues-%d.csv", metrics.WorkspaceID))
+
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"repository", "number", "title", "labels", "createdAt", "startedAt", "closedAt", "leadTimeHours", "cycleTimeHours"})
+
+	for _, issue := range metrics.Issues {
+		var startedAt, cycleTime string
+
+		if issue.StartedAt != nil {
+			startedAt = issue.StartedAt.Format(time.RFC3339)
+		}
+
+		if issue.CycleTimeHours != nil {
+			cycleTime = strconv.FormatFloat(*issue.CycleTimeHours, 'f', 1, 64)
+		}
+
+		writer.Write([]string{
+			issue.Repository,
+			strconv.Itoa(issue.Number),
+			issues.EscapeCSVCell(issue.Title),
+			issues.EscapeCSVCell(strings.Join(issue.Labels, ", ")),
+			issue.CreatedAt.Format(time.RFC3339),
+			startedAt,
+			issue.ClosedAt.Format(time.RFC3339),
+			strconv.FormatFloat(issue.LeadTimeHours, 'f', 1, 64),
+			cycleTime,
+		})
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		log.Errorf("Could not write metrics: %s", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"issues"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/oxisto/go-httputil"
+)
+
+// milestoneFromRequest retrieves the workspace milestone referenced in the request path. If the
+// milestone does not exist, an error response is written and nil is returned
+func (router *Router) milestoneFromRequest(w http.ResponseWriter, r *http.Request, workspace *issues.Workspace) *issues.WorkspaceMilestone {
+	milestone, err := router.app.GetWorkspaceMilestone(workspace.ID, mux.Vars(r)["milestone"])
+	if err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if milestone == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	return milestone
+}
+
+func (router *Router) handleGetMilestones(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	milestones, err := router.app.GetWorkspaceMilestones(workspace.ID)
+
+	httputil.JSONResponse(w, r, milestones, err)
+}
+
+func (router *Router) handleGetMilestone(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone *issues.WorkspaceMilestone
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if milestone = router.milestoneFromRequest(w, r, workspace); milestone == nil {
+		return
+	}
+
+	httputil.JSONResponse(w, r, milestone, nil)
+}
+
+func (router *Router) handleCreateMilestone(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone issues.WorkspaceMilestone
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &milestone); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.CreateWorkspaceMilestone(clients, workspace, &milestone); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponseWithStatus(w, r, &milestone, nil, http.StatusCreated)
+}
+
+func (router *Router) handleUpdateMilestone(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone *issues.WorkspaceMilestone
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if milestone = router.milestoneFromRequest(w, r, workspace); milestone == nil {
+		return
+	}
+
+	id, title := milestone.ID, milestone.Title
+
+	if err = decodeRequest(r, milestone); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	milestone.ID, milestone.WorkspaceID = id, workspace.ID
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.UpdateWorkspaceMilestone(clients, workspace, milestone, title); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, milestone, nil)
+}
+
+func (router *Router) handleSyncMilestone(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone *issues.WorkspaceMilestone
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if milestone = router.milestoneFromRequest(w, r, workspace); milestone == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.SyncWorkspaceMilestone(clients, workspace, milestone, milestone.Title); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, milestone, nil)
+}
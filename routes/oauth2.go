@@ -87,9 +87,9 @@ func (router *Router) handleOAuth2Callback(w http.ResponseWriter, r *http.Reques
 
 	// cache the service token
 	err = app.AddServiceToken(&issues.ServiceToken{
-		user.GetID(),
-		issues.ServiceGitHub,
-		serviceToken.AccessToken})
+		UserID:      user.GetID(),
+		Service:     issues.ServiceGitHub,
+		AccessToken: serviceToken.AccessToken})
 	if err != nil {
 		// no chance to recover
 		log.Errorf("Could not add service token to database: %s", err)
@@ -0,0 +1,81 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"issues"
+	"net/http"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/oxisto/go-httputil"
+)
+
+// draftReleasesResponse contains the release notes and the draft releases created from them
+type draftReleasesResponse struct {
+	*issues.ReleaseNotes
+	Releases []*github.RepositoryRelease `json:"releases"`
+}
+
+func (router *Router) handleGetReleaseNotes(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone *issues.WorkspaceMilestone
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if milestone = router.milestoneFromRequest(w, r, workspace); milestone == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	notes, err := router.app.GetReleaseNotes(clients, workspace, milestone)
+
+	httputil.JSONResponse(w, r, notes, err)
+}
+
+func (router *Router) handleCreateDraftReleases(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		milestone *issues.WorkspaceMilestone
+		response  draftReleasesResponse
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if milestone = router.milestoneFromRequest(w, r, workspace); milestone == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if response.ReleaseNotes, err = router.app.GetReleaseNotes(clients, workspace, milestone); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if response.Releases, err = router.app.CreateDraftReleases(clients, workspace, response.ReleaseNotes); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponseWithStatus(w, r, &response, nil, http.StatusCreated)
+}
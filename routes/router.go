@@ -139,8 +139,60 @@ func NewRouter(app *issues.Application, jwtSecret string) *Router {
 	router.HandleFunc("/oauth2/login", router.handleOAuth2Login)
 	router.HandleFunc("/github/callback", router.handleGitHubCallback).Methods("POST")
 	router.Handle("/api/v1/workspaces/", router.WithMiddleware(handler, router.handleGetWorkspaces)).Methods("GET")
+	router.Handle("/api/v1/workspaces/", router.WithMiddleware(handler, router.handleCreateWorkspace)).Methods("POST")
 	router.Handle("/api/v1/workspaces/{workspaceID}", router.WithMiddleware(handler, router.handleGetWorkspace)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}", router.WithMiddleware(handler, router.handleUpdateWorkspace)).Methods("PATCH")
+	router.Handle("/api/v1/workspaces/{workspaceID}", router.WithMiddleware(handler, router.handleDeleteWorkspace)).Methods("DELETE")
+	router.Handle("/api/v1/workspaces/{workspaceID}/repositories/", router.WithMiddleware(handler, router.handleAddWorkspaceRepository)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/repositories/{repositoryID}", router.WithMiddleware(handler, router.handleRemoveWorkspaceRepository)).Methods("DELETE")
+	router.Handle("/api/v1/workspaces/{workspaceID}/members/", router.WithMiddleware(handler, router.handleGetWorkspaceMembers)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/members/", router.WithMiddleware(handler, router.handleInviteWorkspaceMember)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/members/{userID}", router.WithMiddleware(handler, router.handleRemoveWorkspaceMember)).Methods("DELETE")
 	router.Handle("/api/v1/workspaces/{workspaceID}/issues", router.WithMiddleware(handler, router.handleGetIssues)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/backlog/order", router.WithMiddleware(handler, router.handleOrderBacklog)).Methods("PUT")
+	router.Handle("/api/v1/workspaces/{workspaceID}/views/", router.WithMiddleware(handler, router.handleGetViews)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/views/", router.WithMiddleware(handler, router.handleCreateView)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/views/{viewID}", router.WithMiddleware(handler, router.handleGetView)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/views/{viewID}", router.WithMiddleware(handler, router.handleUpdateView)).Methods("PATCH")
+	router.Handle("/api/v1/workspaces/{workspaceID}/views/{viewID}", router.WithMiddleware(handler, router.handleDeleteView)).Methods("DELETE")
+	router.Handle("/api/v1/workspaces/{workspaceID}/board", router.WithMiddleware(handler, router.handleGetBoard)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/board/columns", router.WithMiddleware(handler, router.handleGetBoardColumns)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/board/columns", router.WithMiddleware(handler, router.handleSetBoardColumns)).Methods("PUT")
+	router.Handle("/api/v1/workspaces/{workspaceID}/board/move", router.WithMiddleware(handler, router.handleMoveBoardIssue)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/", router.WithMiddleware(handler, router.handleGetMilestones)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/", router.WithMiddleware(handler, router.handleCreateMilestone)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/{milestone}", router.WithMiddleware(handler, router.handleGetMilestone)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/{milestone}", router.WithMiddleware(handler, router.handleUpdateMilestone)).Methods("PATCH")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/{milestone}/sync", router.WithMiddleware(handler, router.handleSyncMilestone)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/labels/", router.WithMiddleware(handler, router.handleGetLabels)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/labels/", router.WithMiddleware(handler, router.handleSetLabels)).Methods("PUT")
+	router.Handle("/api/v1/workspaces/{workspaceID}/labels/sync", router.WithMiddleware(handler, router.handleSyncLabels)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/", router.WithMiddleware(handler, router.handleGetIterations)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/", router.WithMiddleware(handler, router.handleCreateIteration)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/{iterationID}", router.WithMiddleware(handler, router.handleGetIteration)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/{iterationID}", router.WithMiddleware(handler, router.handleUpdateIteration)).Methods("PATCH")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/{iterationID}", router.WithMiddleware(handler, router.handleDeleteIteration)).Methods("DELETE")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/{iterationID}/issues/", router.WithMiddleware(handler, router.handleAssignIteration)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/{iterationID}/issues/{issueID}", router.WithMiddleware(handler, router.handleUnassignIteration)).Methods("DELETE")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/{milestone}/estimate", router.WithMiddleware(handler, router.handleGetMilestoneEstimate)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/epics/{owner}/{name}/{number}/estimate", router.WithMiddleware(handler, router.handleGetEpicEstimate)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/estimates/", router.WithMiddleware(handler, router.handleSetEstimate)).Methods("PUT")
+	router.Handle("/api/v1/workspaces/{workspaceID}/fields/", router.WithMiddleware(handler, router.handleGetCustomFields)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/fields/", router.WithMiddleware(handler, router.handleCreateCustomField)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/fields/{fieldID}", router.WithMiddleware(handler, router.handleUpdateCustomField)).Methods("PATCH")
+	router.Handle("/api/v1/workspaces/{workspaceID}/fields/{fieldID}", router.WithMiddleware(handler, router.handleDeleteCustomField)).Methods("DELETE")
+	router.Handle("/api/v1/workspaces/{workspaceID}/fields/{fieldID}/values/", router.WithMiddleware(handler, router.handleSetCustomFieldValue)).Methods("PUT")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/{milestone}/burndown", router.WithMiddleware(handler, router.handleGetMilestoneBurndown)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/iterations/{iterationID}/burndown", router.WithMiddleware(handler, router.handleGetIterationBurndown)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/metrics", router.WithMiddleware(handler, router.handleGetMetrics)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/velocity", router.WithMiddleware(handler, router.handleGetVelocity)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/{milestone}/release-notes", router.WithMiddleware(handler, router.handleGetReleaseNotes)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/milestones/{milestone}/releases", router.WithMiddleware(handler, router.handleCreateDraftReleases)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/stale", router.WithMiddleware(handler, router.handleGetStaleSettings)).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/stale", router.WithMiddleware(handler, router.handleUpdateStaleSettings)).Methods("PUT")
+	router.HandleFunc("/api/v1/workspaces/{workspaceID}/calendar.ics", router.handleGetCalendar).Methods("GET")
+	router.Handle("/api/v1/workspaces/{workspaceID}/calendar/token", router.WithMiddleware(handler, router.handleRotateCalendarToken)).Methods("POST")
+	router.Handle("/api/v1/workspaces/{workspaceID}/calendar/token", router.WithMiddleware(handler, router.handleRevokeCalendarToken)).Methods("DELETE")
 	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./frontend/dist")))
 
 	return router
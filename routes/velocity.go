@@ -0,0 +1,49 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"fmt"
+	"issues"
+	"net/http"
+	"strconv"
+
+	"github.com/oxisto/go-httputil"
+)
+
+func (router *Router) handleGetVelocity(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		window    = issues.VelocityDefaultWindow
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if value := r.URL.Query().Get("window"); value != "" {
+		if window, err = strconv.Atoi(value); err != nil {
+			errorResponse(w, r, fmt.Errorf("%w: invalid window %s", issues.ErrValidationFailed, value))
+			return
+		}
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	velocity, err := router.app.GetVelocity(clients, workspace, window, r.URL.Query().Get("milestone"))
+
+	httputil.JSONResponse(w, r, velocity, err)
+}
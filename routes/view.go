@@ -0,0 +1,194 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routes
+
+import (
+	"fmt"
+	"issues"
+	"net/http"
+	"strconv"
+
+	"github.com/oxisto/go-httputil"
+)
+
+// viewFromRequest retrieves the view referenced in the request path, if it is visible to the
+// authenticated user. Otherwise, an error response is written and nil is returned
+func (router *Router) viewFromRequest(w http.ResponseWriter, r *http.Request, workspace *issues.Workspace) *issues.View {
+	viewID, err := int64FromRequest(r, "viewID")
+	if err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	return router.view(w, r, workspace, viewID)
+}
+
+// viewFromQuery retrieves the view referenced in the view query parameter, if it is visible to
+// the authenticated user. Otherwise, an error response is written and nil is returned
+func (router *Router) viewFromQuery(w http.ResponseWriter, r *http.Request, workspace *issues.Workspace) *issues.View {
+	viewID, err := strconv.ParseInt(r.URL.Query().Get("view"), 10, 64)
+	if err != nil {
+		errorResponse(w, r, fmt.Errorf("%w: invalid view", issues.ErrValidationFailed))
+		return nil
+	}
+
+	return router.view(w, r, workspace, viewID)
+}
+
+func (router *Router) view(w http.ResponseWriter, r *http.Request, workspace *issues.Workspace, viewID int64) *issues.View {
+	var (
+		view *issues.View
+		err  error
+	)
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if view, err = router.app.GetView(workspace.ID, viewID, clients.User.GetID()); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if view == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	return view
+}
+
+func (router *Router) handleGetViews(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	views, err := router.app.GetViews(workspace.ID, clients.User.GetID())
+
+	httputil.JSONResponse(w, r, views, err)
+}
+
+func (router *Router) handleGetView(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		view      *issues.View
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if view = router.viewFromRequest(w, r, workspace); view == nil {
+		return
+	}
+
+	httputil.JSONResponse(w, r, view, nil)
+}
+
+func (router *Router) handleCreateView(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		view      issues.View
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &view); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.CreateView(workspace.ID, clients.User.GetID(), &view); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponseWithStatus(w, r, &view, nil, http.StatusCreated)
+}
+
+func (router *Router) handleUpdateView(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		view      *issues.View
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if view = router.viewFromRequest(w, r, workspace); view == nil {
+		return
+	}
+
+	id, workspaceID, ownerID := view.ID, view.WorkspaceID, view.OwnerID
+
+	if err = decodeRequest(r, view); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	// these cannot be changed by the request
+	view.ID, view.WorkspaceID, view.OwnerID = id, workspaceID, ownerID
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.UpdateView(view, clients.User.GetID()); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, view, nil)
+}
+
+func (router *Router) handleDeleteView(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		view      *issues.View
+		role      issues.Role
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if view = router.viewFromRequest(w, r, workspace); view == nil {
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if role, err = router.app.GetRole(clients, workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if err = router.app.DeleteView(view, clients.User.GetID(), role); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -15,53 +15,438 @@
 package routes
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"issues"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/oxisto/go-httputil"
 )
 
-func (router *Router) handleGetWorkspaces(w http.ResponseWriter, r *http.Request) {
-	workspaces, err := router.app.GetDatabase().GetWorkspaces(nil)
+// workspaceRequest contains the modifiable fields of a workspace
+type workspaceRequest struct {
+	Name *string `json:"name"`
+}
 
-	httputil.JSONResponse(w, r, workspaces, err)
+// memberRequest invites a GitHub user to a workspace
+type memberRequest struct {
+	Login string      `json:"login"`
+	Role  issues.Role `json:"role"`
 }
 
-func (router *Router) handleGetWorkspace(w http.ResponseWriter, r *http.Request) {
+// orderRequest moves an issue in the backlog between two neighbouring issues
+type orderRequest struct {
+	IssueID string `json:"issueID"`
+	After   string `json:"after"`
+	Before  string `json:"before"`
+}
+
+// repositoryRequest references a repository to add to a workspace
+type repositoryRequest struct {
+	RepositoryID int64 `json:"repositoryID"`
+}
+
+// errorResponse returns the error with a status code suitable for the error
+func errorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var status = http.StatusInternalServerError
+
+	if errors.Is(err, issues.ErrValidationFailed) {
+		status = http.StatusBadRequest
+	} else if errors.Is(err, issues.ErrAccessDenied) {
+		status = http.StatusForbidden
+	} else if errors.Is(err, issues.ErrWIPLimitReached) {
+		status = http.StatusConflict
+	}
+
+	log.Errorf("An error occured during processing of a REST request: %s", err)
+	http.Error(w, err.Error(), status)
+}
+
+// decodeRequest decodes the JSON body of the request into object
+func decodeRequest(r *http.Request, object interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(object); err != nil {
+		return fmt.Errorf("%w: could not decode request: %s", issues.ErrValidationFailed, err)
+	}
+
+	return nil
+}
+
+// int64FromRequest parses a numeric path variable of the request
+func int64FromRequest(r *http.Request, name string) (int64, error) {
+	i, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s", issues.ErrValidationFailed, name)
+	}
+
+	return i, nil
+}
+
+// dateFromRequest parses the date in the query parameter. If it is not set, the default is returned
+func dateFromRequest(r *http.Request, name string, def time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return date, fmt.Errorf("%w: invalid date %s", issues.ErrValidationFailed, value)
+	}
+
+	return date, nil
+}
+
+// workspaceFromRequest retrieves the workspace referenced in the request path and checks, whether
+// the authenticated user has at least the specified role in it. If the workspace could not be
+// retrieved or the user lacks the role, an error response is written and nil is returned
+func (router *Router) workspaceFromRequest(w http.ResponseWriter, r *http.Request, role issues.Role) *issues.Workspace {
 	var (
 		workspaceID int64
 		workspace   *issues.Workspace
 		err         error
 	)
 
-	if workspaceID, err = strconv.ParseInt(mux.Vars(r)["workspaceID"], 10, 64); err != nil {
-		httputil.JSONResponse(w, r, nil, err)
-		return
+	if workspaceID, err = int64FromRequest(r, "workspaceID"); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	if workspace, err = router.app.GetWorkspace(workspaceID); err != nil {
+		errorResponse(w, r, err)
+		return nil
 	}
 
-	// TODO: check somehow, if user has access
-	workspace, err = router.app.GetWorkspace(workspaceID)
-	httputil.JSONResponse(w, r, workspace, err)
+	if workspace == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.CheckRole(clients, workspace, role); err != nil {
+		errorResponse(w, r, err)
+		return nil
+	}
+
+	return workspace
+}
+
+func (router *Router) handleGetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	workspaces, err := router.app.GetAccessibleWorkspaces(clients)
 
-	return
+	httputil.JSONResponse(w, r, workspaces, err)
+}
+
+func (router *Router) handleGetWorkspace(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	httputil.JSONResponse(w, r, workspace, nil)
 }
 
 func (router *Router) handleGetIssues(w http.ResponseWriter, r *http.Request) {
 	var (
-		workspaceID int64
-		err         error
+		workspace *issues.Workspace
+		view      *issues.View
+		query     *issues.BacklogQuery
+		backlog   *issues.Backlog
+		stream    *issues.BacklogStream
+		format    string
+		columns   []string
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	if format, err = formatFromRequest(r, FormatCSV, FormatXLSX); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	// the query of a view can be further refined by the query parameter
+	if r.URL.Query().Get("view") != "" {
+		if view = router.viewFromQuery(w, r, workspace); view == nil {
+			return
+		}
+
+		query, err = view.BacklogQuery(r.URL.Query().Get("q"))
+	} else {
+		query, err = issues.ParseBacklogQuery(r.URL.Query().Get("q"))
+	}
+
+	if err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if err = router.app.ValidateBacklogQuery(workspace.ID, query); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if format != FormatJSON {
+		if columns = router.exportColumnsFromRequest(w, r, workspace, view); columns == nil {
+			return
+		}
+	}
+
+	// exports are streamed, unless the issues need to be sorted as a whole
+	if format != FormatJSON && query.Streamable() {
+		if stream, err = router.app.StreamBacklog(clients, workspace, query, clients.User.GetLogin()); err != nil {
+			errorResponse(w, r, err)
+			return
+		}
+
+		if err = issues.WriteBacklogStream(tableResponse(w, format, fmt.Sprintf("backlog-%d", workspace.ID)), stream, columns); err != nil {
+			log.Errorf("Could not write backlog: %s", err)
+		}
+
+		return
+	}
+
+	if backlog, err = router.app.GetBacklog(clients, workspace.ID); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	backlog.Issues = query.Apply(backlog.Issues, clients.User.GetLogin())
+
+	if format != FormatJSON {
+		if err = issues.WriteBacklog(tableResponse(w, format, fmt.Sprintf("backlog-%d", workspace.ID)), backlog.Issues, columns); err != nil {
+			log.Errorf("Could not write backlog: %s", err)
+		}
+
+		return
+	}
+
+	httputil.JSONResponse(w, r, backlog, nil)
+}
+
+func (router *Router) handleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace issues.Workspace
+		err       error
+	)
+
+	if err = decodeRequest(r, &workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	// repositories need to be added explicitly, so that they can be validated
+	workspace.RepositoryIDs = nil
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.CreateWorkspace(clients, &workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponseWithStatus(w, r, &workspace, nil, http.StatusCreated)
+}
+
+func (router *Router) handleUpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var (
+		request   workspaceRequest
+		workspace *issues.Workspace
+		err       error
 	)
-	if workspaceID, err = strconv.ParseInt(mux.Vars(r)["workspaceID"], 10, 64); err != nil {
-		httputil.JSONResponse(w, r, nil, err)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if request.Name != nil {
+		workspace.Name = *request.Name
+	}
+
+	if err = router.app.UpdateWorkspace(workspace); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, workspace, nil)
+}
+
+func (router *Router) handleDeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleOwner); workspace == nil {
+		return
+	}
+
+	if err = router.app.DeleteWorkspace(workspace.ID); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (router *Router) handleAddWorkspaceRepository(w http.ResponseWriter, r *http.Request) {
+	var (
+		request   repositoryRequest
+		workspace *issues.Workspace
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.AddWorkspaceRepository(clients, workspace, request.RepositoryID); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, workspace, nil)
+}
+
+func (router *Router) handleRemoveWorkspaceRepository(w http.ResponseWriter, r *http.Request) {
+	var (
+		repositoryID int64
+		workspace    *issues.Workspace
+		err          error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if repositoryID, err = int64FromRequest(r, "repositoryID"); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	if err = router.app.RemoveWorkspaceRepository(workspace, repositoryID); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, workspace, nil)
+}
+
+func (router *Router) handleGetWorkspaceMembers(w http.ResponseWriter, r *http.Request) {
+	var (
+		workspace *issues.Workspace
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleViewer); workspace == nil {
+		return
+	}
+
+	members, err := router.app.GetWorkspaceMembers(workspace.ID)
+
+	httputil.JSONResponse(w, r, members, err)
+}
+
+func (router *Router) handleInviteWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	var (
+		request   memberRequest
+		workspace *issues.Workspace
+		member    *issues.WorkspaceMember
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if member, err = router.app.InviteMember(clients, workspace, request.Login, request.Role); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	httputil.JSONResponse(w, r, member, nil)
+}
+
+func (router *Router) handleRemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID    int64
+		workspace *issues.Workspace
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if userID, err = int64FromRequest(r, "userID"); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
+
+	if err = router.app.RemoveMember(clients, workspace, userID); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (router *Router) handleOrderBacklog(w http.ResponseWriter, r *http.Request) {
+	var (
+		request   orderRequest
+		workspace *issues.Workspace
+		rank      *issues.BacklogRank
+		err       error
+	)
+
+	if workspace = router.workspaceFromRequest(w, r, issues.RoleMaintainer); workspace == nil {
+		return
+	}
+
+	if err = decodeRequest(r, &request); err != nil {
+		errorResponse(w, r, err)
 		return
 	}
 
 	clients := r.Context().Value(issues.ServiceGitHub).(*issues.GitHubClients)
 
-	issues, err := router.app.GetBacklog(clients, workspaceID)
-	// TODO: check somehow, if user has access
+	if rank, err = router.app.MoveBacklogIssue(clients, workspace, request.IssueID, request.After, request.Before); err != nil {
+		errorResponse(w, r, err)
+		return
+	}
 
-	httputil.JSONResponse(w, r, issues, err)
+	httputil.JSONResponse(w, r, rank, nil)
 }
@@ -0,0 +1,42 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"time"
+)
+
+// Schedule runs the job in the background, once immediately and then in the specified interval.
+// Errors of the job are logged, but do not stop the schedule
+func (app *Application) Schedule(name string, interval time.Duration, job func() error) {
+	log.Infof("Scheduling job %s every %v", name, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			start := time.Now()
+
+			if err := job(); err != nil {
+				log.Errorf("Job %s failed: %s", name, err)
+			} else {
+				log.Infof("Job %s took %+v", name, time.Since(start))
+			}
+
+			<-ticker.C
+		}
+	}()
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/bradleyfalzon/ghinstallation"
 	"github.com/google/go-github/v29/github"
@@ -31,6 +32,15 @@ import (
 // TokenCache contains a simple cache of github clients
 var clients map[int64]*GitHubClients
 var installationClients map[int64]*GitHubClients
+var installationClientsMutex sync.RWMutex
+
+// repositoryInstallations caches the installation IDs of repositories. It is used by webhooks,
+// requests and scheduled jobs at the same time, so it needs to be locked
+var repositoryInstallations map[int64]int64
+var repositoryInstallationsMutex sync.RWMutex
+
+// PrivateKeyFile is the private key of the GitHub App
+const PrivateKeyFile = "keys/private-key.pem"
 
 var ErrAuthenticationNeeded = errors.New("You need to authenticate with the service")
 
@@ -98,7 +108,7 @@ func (app *Application) newGitHubClients(userID int64) (clients *GitHubClients,
 func (app *Application) newGitHubInstallationClients(installationID int64) (clients *GitHubClients, err error) {
 	tr := http.DefaultTransport
 
-	itr, err := ghinstallation.NewKeyFromFile(tr, app.AppID, installationID, "keys/private-key.pem")
+	itr, err := ghinstallation.NewKeyFromFile(tr, app.AppID, installationID, PrivateKeyFile)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +130,7 @@ func (app *Application) newGitHubInstallationClients(installationID int64) (clie
 func init() {
 	clients = make(map[int64]*GitHubClients)
 	installationClients = make(map[int64]*GitHubClients)
+	repositoryInstallations = make(map[int64]int64)
 }
 
 func (app *Application) AddServiceToken(token *ServiceToken) (err error) {
@@ -176,7 +187,9 @@ func (app *Application) GetInstallationClients(installationID int64) (c *GitHubC
 		found bool
 	)
 
+	installationClientsMutex.RLock()
 	c, found = installationClients[installationID]
+	installationClientsMutex.RUnlock()
 
 	if found {
 		log.Debugf("Using in-memory GitHub clients for installation %d", installationID)
@@ -187,6 +200,43 @@ func (app *Application) GetInstallationClients(installationID int64) (c *GitHubC
 		return nil, err
 	}
 
+	installationClientsMutex.Lock()
 	installationClients[installationID] = c
+	installationClientsMutex.Unlock()
+
 	return
 }
+
+// GetRepositoryInstallationClients returns the installation clients of the installation of our
+// GitHub App, that has access to the specified repository
+func (app *Application) GetRepositoryInstallationClients(repositoryID int64) (c *GitHubClients, err error) {
+	var (
+		found        bool
+		atr          *ghinstallation.AppsTransport
+		installation *github.Installation
+	)
+
+	repositoryInstallationsMutex.RLock()
+	installationID, found := repositoryInstallations[repositoryID]
+	repositoryInstallationsMutex.RUnlock()
+
+	if !found {
+		// we need to authenticate as the app itself to look up the installation
+		if atr, err = ghinstallation.NewAppsTransportKeyFromFile(http.DefaultTransport, app.AppID, PrivateKeyFile); err != nil {
+			return nil, err
+		}
+
+		appClient := github.NewClient(&http.Client{Transport: atr})
+
+		if installation, _, err = appClient.Apps.FindRepositoryInstallationByID(context.Background(), repositoryID); err != nil {
+			return nil, fmt.Errorf("Could not find installation for repository %d: %w", repositoryID, err)
+		}
+
+		installationID = installation.GetID()
+		repositoryInstallationsMutex.Lock()
+		repositoryInstallations[repositoryID] = installationID
+		repositoryInstallationsMutex.Unlock()
+	}
+
+	return app.GetInstallationClients(installationID)
+}
@@ -0,0 +1,461 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/lib/pq"
+)
+
+// StaleLabel is the label of issues without recent activity
+const StaleLabel = "stale"
+
+// DefaultStaleDays is the number of days without activity after which issues are considered stale,
+// if a workspace did not configure it
+const DefaultStaleDays = 60
+
+// AnyMilestone can be used as exempt milestone to exempt all issues that are part of a milestone
+const AnyMilestone = "*"
+
+// StaleAction is the action the stale issue job takes for an issue
+type StaleAction int
+
+// Actions of the stale issue job
+const (
+	StaleActionNone StaleAction = iota
+	StaleActionMark
+	StaleActionUnmark
+	StaleActionClose
+)
+
+// StaleSettings configure the detection of stale issues in the repositories of a workspace
+type StaleSettings struct {
+	WorkspaceID int64 `json:"workspaceID" gorm:"primary_key;auto_increment:false"`
+	Enabled     bool  `json:"enabled"`
+	// Days is the number of days without activity after which an issue is marked as stale
+	Days int `json:"days"`
+	// CloseAfterDays is the grace period after which stale issues are closed. Stale issues are
+	// never closed, if it is 0
+	CloseAfterDays int `json:"closeAfterDays"`
+	// ExemptLabels contains labels of issues that never become stale
+	ExemptLabels pq.StringArray `json:"exemptLabels" gorm:"type:text[]"`
+	// ExemptMilestones contains titles of milestones whose issues never become stale
+	ExemptMilestones pq.StringArray `json:"exemptMilestones" gorm:"type:text[]"`
+	// ExemptEpics exempts epics and issues that are part of an epic
+	ExemptEpics bool `json:"exemptEpics"`
+}
+
+// Validate checks, whether the settings can be stored
+func (s *StaleSettings) Validate() error {
+	if s.Days <= 0 {
+		return fmt.Errorf("%w: days must be positive", ErrValidationFailed)
+	}
+
+	if s.CloseAfterDays < 0 {
+		return fmt.Errorf("%w: close after days must not be negative", ErrValidationFailed)
+	}
+
+	s.ExemptLabels = trimStrings(s.ExemptLabels)
+	s.ExemptMilestones = trimStrings(s.ExemptMilestones)
+
+	return nil
+}
+
+func trimStrings(values []string) (trimmed pq.StringArray) {
+	trimmed = pq.StringArray{}
+
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+
+	return trimmed
+}
+
+// IsExempt checks, whether the issue never becomes stale. Epic items contains the numbers of all
+// issues of the repository that are part of an epic
+func (s *StaleSettings) IsExempt(issue *github.Issue, epicItems map[int]bool) bool {
+	for _, label := range issue.Labels {
+		for _, exempt := range s.ExemptLabels {
+			if strings.EqualFold(label.GetName(), exempt) {
+				return true
+			}
+		}
+	}
+
+	if issue.Milestone != nil {
+		for _, exempt := range s.ExemptMilestones {
+			if exempt == AnyMilestone || exempt == issue.Milestone.GetTitle() {
+				return true
+			}
+		}
+	}
+
+	if s.ExemptEpics {
+		if epicItems[issue.GetNumber()] || len(EpicItemNumbers(issue.GetBody())) > 0 || len(EpicNumbers(issue.GetBody())) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetStaleAction decides what to do with an open issue. Marking an issue as stale is activity
+// itself, so the grace period of issues marked as stale starts at their last update
+func (s *StaleSettings) GetStaleAction(issue *github.Issue, epicItems map[int]bool, now time.Time) StaleAction {
+	stale := hasLabel(issue, StaleLabel)
+	inactive := now.Sub(issue.GetUpdatedAt())
+
+	if s.IsExempt(issue, epicItems) {
+		if stale {
+			return StaleActionUnmark
+		}
+
+		return StaleActionNone
+	}
+
+	if !stale {
+		if inactive >= time.Duration(s.Days)*24*time.Hour {
+			return StaleActionMark
+		}
+
+		return StaleActionNone
+	}
+
+	if s.CloseAfterDays > 0 && inactive >= time.Duration(s.CloseAfterDays)*24*time.Hour {
+		return StaleActionClose
+	}
+
+	return StaleActionNone
+}
+
+// GetStaleSettings returns the stale settings of the workspace. If the workspace has none, the
+// disabled default settings are returned
+func (app *Application) GetStaleSettings(workspaceID int64) (*StaleSettings, error) {
+	settings, err := app.db.GetStaleSettings("workspace_id = ?", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings) == 0 {
+		return &StaleSettings{
+			WorkspaceID:      workspaceID,
+			Days:             DefaultStaleDays,
+			ExemptLabels:     pq.StringArray{},
+			ExemptMilestones: pq.StringArray{},
+		}, nil
+	}
+
+	return settings[0], nil
+}
+
+// UpdateStaleSettings validates and stores the stale settings of the workspace. Since the job
+// labels, comments on and closes issues using the installation clients of our app, it can only be
+// enabled by users, who can push to all repositories of the workspace. A repository can only be
+// checked by one workspace
+func (app *Application) UpdateStaleSettings(clients *GitHubClients, workspace *Workspace, settings *StaleSettings) (err error) {
+	var allowed bool
+
+	settings.WorkspaceID = workspace.ID
+
+	if err = settings.Validate(); err != nil {
+		return err
+	}
+
+	if settings.Enabled {
+		if allowed, err = app.CanPushRepositories(clients, workspace.RepositoryIDs); err != nil {
+			return err
+		}
+
+		if !allowed {
+			return fmt.Errorf("%w: stale issue detection can only be enabled by users with push access to all repositories", ErrAccessDenied)
+		}
+
+		if err = app.checkStaleConflicts(workspace.ID, workspace.RepositoryIDs); err != nil {
+			return err
+		}
+	}
+
+	_, err = app.db.Update(settings)
+
+	return
+}
+
+// checkStaleConflicts makes sure, that none of the repositories is already checked for stale
+// issues by another workspace
+func (app *Application) checkStaleConflicts(workspaceID int64, repositoryIDs []int64) (err error) {
+	var (
+		settings []*StaleSettings
+		other    *Workspace
+	)
+
+	if settings, err = app.db.GetStaleSettings("enabled = ? AND workspace_id <> ?", true, workspaceID); err != nil {
+		return err
+	}
+
+	for _, s := range settings {
+		if other, err = app.GetWorkspace(s.WorkspaceID); err != nil {
+			return err
+		}
+
+		if other == nil {
+			continue
+		}
+
+		for _, repositoryID := range repositoryIDs {
+			if other.RepositoryIDs.Contains(repositoryID) {
+				return fmt.Errorf("%w: repository %d is already checked for stale issues by another workspace", ErrValidationFailed, repositoryID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkStaleRepository makes sure, that a repository can be added to the workspace without
+// circumventing the checks of UpdateStaleSettings
+func (app *Application) checkStaleRepository(clients *GitHubClients, workspace *Workspace, repositoryID int64) (err error) {
+	var (
+		settings *StaleSettings
+		allowed  bool
+	)
+
+	if settings, err = app.GetStaleSettings(workspace.ID); err != nil {
+		return err
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	if allowed, err = app.CanPushRepositories(clients, []int64{repositoryID}); err != nil {
+		return err
+	}
+
+	if !allowed {
+		return fmt.Errorf("%w: workspaces checking for stale issues can only contain repositories you can push to", ErrAccessDenied)
+	}
+
+	return app.checkStaleConflicts(workspace.ID, []int64{repositoryID})
+}
+
+// MarkStaleIssues marks inactive issues of all workspaces with enabled stale settings as stale and
+// closes them after their grace period. Conflicting settings are refused when they are stored, but
+// if a repository still belongs to several workspaces, only the settings of the oldest workspace
+// are used. Since there is no user, the installation clients are used
+func (app *Application) MarkStaleIssues() (err error) {
+	var (
+		settings  []*StaleSettings
+		workspace *Workspace
+	)
+
+	if settings, err = app.db.GetStaleSettings("enabled = ?", true); err != nil {
+		return fmt.Errorf("Could not fetch stale settings from database: %w", err)
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].WorkspaceID < settings[j].WorkspaceID
+	})
+
+	processed := make(map[int64]bool)
+	now := time.Now()
+
+	for _, s := range settings {
+		if workspace, err = app.GetWorkspace(s.WorkspaceID); err != nil {
+			return err
+		}
+
+		// settings of deleted workspaces might be left over
+		if workspace == nil {
+			continue
+		}
+
+		for _, repositoryID := range workspace.RepositoryIDs {
+			if processed[repositoryID] {
+				continue
+			}
+
+			processed[repositoryID] = true
+
+			if err = app.markRepositoryStaleIssues(repositoryID, s, now); err != nil {
+				log.Errorf("Could not mark stale issues of repository %d: %s", repositoryID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (app *Application) markRepositoryStaleIssues(repositoryID int64, settings *StaleSettings, now time.Time) (err error) {
+	var (
+		clients *GitHubClients
+		repo    *github.Repository
+		issues  []*github.Issue
+	)
+
+	if clients, err = app.GetRepositoryInstallationClients(repositoryID); err != nil {
+		return err
+	}
+
+	if repo, _, err = clients.V3.Repositories.GetByID(context.Background(), repositoryID); err != nil {
+		return fmt.Errorf("Could not retrieve repository %d: %w", repositoryID, err)
+	}
+
+	if issues, err = listOpenIssues(clients, repo); err != nil {
+		return err
+	}
+
+	epicItems := make(map[int]bool)
+	for _, issue := range issues {
+		for _, number := range EpicItemNumbers(issue.GetBody()) {
+			epicItems[number] = true
+		}
+	}
+
+	for _, issue := range issues {
+		switch settings.GetStaleAction(issue, epicItems, now) {
+		case StaleActionMark:
+			err = markStale(clients, repo, issue, settings)
+		case StaleActionUnmark:
+			err = RemoveStaleLabel(clients, repo, issue)
+		case StaleActionClose:
+			err = closeStale(clients, repo, issue, settings)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listOpenIssues retrieves all open issues of the repository, without pull requests
+func listOpenIssues(clients *GitHubClients, repo *github.Repository) (issues []*github.Issue, err error) {
+	options := github.IssueListByRepoOptions{
+		State:       StateOpen,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		result, resp, err := clients.V3.Issues.ListByRepo(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), &options)
+		if err != nil {
+			return nil, fmt.Errorf("Could not list issues of %s: %w", repo.GetFullName(), err)
+		}
+
+		for _, issue := range result {
+			if !issue.IsPullRequest() {
+				issues = append(issues, issue)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return issues, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+func markStale(clients *GitHubClients, repo *github.Repository, issue *github.Issue, settings *StaleSettings) (err error) {
+	if _, _, err = clients.V3.Issues.AddLabelsToIssue(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), []string{StaleLabel}); err != nil {
+		return fmt.Errorf("Could not mark %s as stale: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	body := fmt.Sprintf("This issue has had no activity for %d days and is now marked as **%s**.", settings.Days, StaleLabel)
+	if settings.CloseAfterDays > 0 {
+		body += fmt.Sprintf(" It will be closed in %d days, unless there is new activity.", settings.CloseAfterDays)
+	}
+
+	if _, _, err = clients.V3.Issues.CreateComment(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueComment{
+		Body: &body,
+	}); err != nil {
+		return fmt.Errorf("Creating comment for issue %s failed: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	log.Infof("Marked issue %s as stale", GetIssueIdentifier(repo, issue))
+
+	return nil
+}
+
+func closeStale(clients *GitHubClients, repo *github.Repository, issue *github.Issue, settings *StaleSettings) (err error) {
+	body := fmt.Sprintf("This issue is closed, because it has been **%s** for %d days without activity.", StaleLabel, settings.CloseAfterDays)
+
+	if _, _, err = clients.V3.Issues.CreateComment(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueComment{
+		Body: &body,
+	}); err != nil {
+		return fmt.Errorf("Creating comment for issue %s failed: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	state := StateClosed
+
+	if _, _, err = clients.V3.Issues.Edit(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), &github.IssueRequest{
+		State: &state,
+	}); err != nil {
+		return fmt.Errorf("Could not close stale issue %s: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	log.Infof("Closed stale issue %s", GetIssueIdentifier(repo, issue))
+
+	return nil
+}
+
+// IsStaleDetectionEnabled checks, whether the repository belongs to a workspace with enabled stale
+// issue detection
+func (app *Application) IsStaleDetectionEnabled(repositoryID int64) (enabled bool, err error) {
+	var (
+		settings  []*StaleSettings
+		workspace *Workspace
+	)
+
+	if settings, err = app.db.GetStaleSettings("enabled = ?", true); err != nil {
+		return false, err
+	}
+
+	for _, s := range settings {
+		if workspace, err = app.GetWorkspace(s.WorkspaceID); err != nil {
+			return false, err
+		}
+
+		if workspace != nil && workspace.RepositoryIDs.Contains(repositoryID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RemoveStaleLabel removes the stale label from the issue, if it has one. This is used, when there
+// is new activity on the issue
+func RemoveStaleLabel(clients *GitHubClients, repo *github.Repository, issue *github.Issue) (err error) {
+	if !hasLabel(issue, StaleLabel) {
+		return nil
+	}
+
+	if _, err = clients.V3.Issues.RemoveLabelForIssue(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), issue.GetNumber(), StaleLabel); err != nil {
+		return fmt.Errorf("Could not remove stale label from %s: %w", GetIssueIdentifier(repo, issue), err)
+	}
+
+	log.Infof("Removed stale label from issue %s", GetIssueIdentifier(repo, issue))
+
+	return nil
+}
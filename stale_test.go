@@ -0,0 +1,83 @@
+package issues
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v29/github"
+	"github.com/lib/pq"
+)
+
+func staleIssue(number int, updatedAt time.Time, labels ...string) *github.Issue {
+	issue := &github.Issue{Number: &number, UpdatedAt: &updatedAt}
+
+	for i := range labels {
+		issue.Labels = append(issue.Labels, github.Label{Name: &labels[i]})
+	}
+
+	return issue
+}
+
+func TestGetStaleAction(t *testing.T) {
+	now := time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)
+	settings := &StaleSettings{Days: 30, CloseAfterDays: 7, ExemptLabels: pq.StringArray{"pinned"}, ExemptMilestones: pq.StringArray{"1.0"}, ExemptEpics: true}
+
+	milestone := staleIssue(5, now.AddDate(0, -2, 0))
+	title := "1.0"
+	milestone.Milestone = &github.Milestone{Title: &title}
+
+	epic := staleIssue(6, now.AddDate(0, -2, 0))
+	body := "- [ ] Movable windows (#7)"
+	epic.Body = &body
+
+	tests := []struct {
+		name     string
+		issue    *github.Issue
+		expected StaleAction
+	}{
+		{"active", staleIssue(1, now.AddDate(0, 0, -29)), StaleActionNone},
+		{"inactive", staleIssue(2, now.AddDate(0, 0, -30)), StaleActionMark},
+		{"grace period", staleIssue(3, now.AddDate(0, 0, -6), StaleLabel), StaleActionNone},
+		{"grace period over", staleIssue(4, now.AddDate(0, 0, -7), StaleLabel), StaleActionClose},
+		{"exempt label", staleIssue(8, now.AddDate(0, -2, 0), "Pinned"), StaleActionNone},
+		{"exempt milestone", milestone, StaleActionNone},
+		{"epic", epic, StaleActionNone},
+		{"epic item", staleIssue(7, now.AddDate(0, -2, 0)), StaleActionNone},
+		{"exempt and stale", staleIssue(9, now.AddDate(0, -2, 0), StaleLabel, "pinned"), StaleActionUnmark},
+	}
+
+	epicItems := map[int]bool{7: true}
+
+	for _, test := range tests {
+		if action := settings.GetStaleAction(test.issue, epicItems, now); action != test.expected {
+			t.Errorf("Expected action %d for %s issue, got %d", test.expected, test.name, action)
+		}
+	}
+
+	settings.CloseAfterDays = 0
+	if action := settings.GetStaleAction(staleIssue(4, now.AddDate(-1, 0, 0), StaleLabel), epicItems, now); action != StaleActionNone {
+		t.Errorf("Expected stale issues never to be closed, got action %d", action)
+	}
+}
+
+func TestStaleSettingsValidate(t *testing.T) {
+	settings := &StaleSettings{Days: 0}
+	if err := settings.Validate(); !errors.Is(err, ErrValidationFailed) {
+		t.Errorf("Expected validation to fail for non-positive days, got %v", err)
+	}
+
+	settings = &StaleSettings{Days: 30, CloseAfterDays: -1}
+	if err := settings.Validate(); !errors.Is(err, ErrValidationFailed) {
+		t.Errorf("Expected validation to fail for negative grace period, got %v", err)
+	}
+
+	settings = &StaleSettings{Days: 30, ExemptLabels: pq.StringArray{" pinned ", ""}}
+	if err := settings.Validate(); err != nil {
+		t.Fatalf("Expected settings to be valid, got %s", err)
+	}
+
+	if len(settings.ExemptLabels) != 1 || settings.ExemptLabels[0] != "pinned" {
+		t.Errorf("Expected exempt labels to be trimmed, got %v", settings.ExemptLabels)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// VelocityDefaultWindow is the number of iterations the rolling average is computed of
+const VelocityDefaultWindow = 3
+
+// Velocity contains the work completed in the past iterations of a workspace and optionally a
+// forecast for the remaining scope of a milestone
+type Velocity struct {
+	WorkspaceID int64                `json:"workspaceID"`
+	Window      int                  `json:"window"`
+	Iterations  []*IterationVelocity `json:"iterations"`
+	// AveragePoints is the rolling average of the completed points of the last iterations
+	AveragePoints float64           `json:"averagePoints"`
+	Forecast      *VelocityForecast `json:"forecast,omitempty"`
+}
+
+// IterationVelocity contains the committed and completed work of an iteration. Only issues closed
+// during the iteration count as completed
+type IterationVelocity struct {
+	IterationID     int64     `json:"iterationID"`
+	Name            string    `json:"name"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	CommittedIssues int       `json:"committedIssues"`
+	CompletedIssues int       `json:"completedIssues"`
+	CommittedPoints float64   `json:"committedPoints"`
+	CompletedPoints float64   `json:"completedPoints"`
+	// RollingAverage is the average of the completed points of this and the previous iterations
+	RollingAverage float64 `json:"rollingAverage"`
+}
+
+// VelocityForecast estimates how many iterations are needed to complete the open issues of a milestone
+type VelocityForecast struct {
+	Milestone         string  `json:"milestone"`
+	RemainingIssues   int     `json:"remainingIssues"`
+	UnestimatedIssues int     `json:"unestimatedIssues"`
+	RemainingPoints   float64 `json:"remainingPoints"`
+	// Iterations is nil, if there is no velocity to forecast with
+	Iterations *int `json:"iterations"`
+}
+
+// ComputeVelocity computes the velocity of the iterations, which need to be sorted by their
+// start, with the issues assigned to each iteration
+func ComputeVelocity(iterations []*Iteration, issues map[int64][]*BacklogIssue, window int) *Velocity {
+	velocity := &Velocity{Window: window, Iterations: []*IterationVelocity{}}
+
+	for i, iteration := range iterations {
+		v := &IterationVelocity{
+			IterationID: iteration.ID,
+			Name:        iteration.Name,
+			Start:       iteration.Start,
+			End:         iteration.End,
+		}
+
+		for _, issue := range issues[iteration.ID] {
+			var points float64
+			if issue.Estimate != nil {
+				points = *issue.Estimate
+			}
+
+			v.CommittedIssues++
+			v.CommittedPoints += points
+
+			// issues closed before the iteration were only added to it afterwards
+			if issue.ClosedAt != nil && !issue.ClosedAt.Before(iteration.Start) && !issue.ClosedAt.After(iteration.End) {
+				v.CompletedIssues++
+				v.CompletedPoints += points
+			}
+		}
+
+		velocity.Iterations = append(velocity.Iterations, v)
+
+		var sum float64
+		first := i - window + 1
+		if first < 0 {
+			first = 0
+		}
+
+		for _, previous := range velocity.Iterations[first:] {
+			sum += previous.CompletedPoints
+		}
+
+		v.RollingAverage = sum / float64(len(velocity.Iterations[first:]))
+		velocity.AveragePoints = v.RollingAverage
+	}
+
+	return velocity
+}
+
+// ForecastIterations returns the number of iterations needed to complete the remaining points
+// with the average velocity or nil, if there is no velocity
+func ForecastIterations(remainingPoints float64, averagePoints float64) *int {
+	if averagePoints <= 0 {
+		return nil
+	}
+
+	iterations := int(math.Ceil(remainingPoints / averagePoints))
+
+	return &iterations
+}
+
+// GetVelocity computes the velocity of all past iterations of the workspace. If a milestone is
+// specified, the number of iterations needed for its open issues is forecasted
+func (app *Application) GetVelocity(clients *GitHubClients, workspace *Workspace, window int, milestone string) (velocity *Velocity, err error) {
+	var (
+		iterations  []*Iteration
+		assignments []*IterationIssue
+		issues      []*BacklogIssue
+		ids         []string
+	)
+
+	if window < 1 {
+		return nil, fmt.Errorf("%w: window must contain at least one iteration", ErrValidationFailed)
+	}
+
+	if iterations, err = app.db.GetIterations("workspace_id = ? AND \"end\" <= ?", workspace.ID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(iterations, func(i, j int) bool {
+		return iterations[i].Start.Before(iterations[j].Start)
+	})
+
+	iterationIDs := []int64{}
+	for _, iteration := range iterations {
+		iterationIDs = append(iterationIDs, iteration.ID)
+	}
+
+	if len(iterationIDs) > 0 {
+		if assignments, err = app.db.GetIterationIssues("iteration_id IN (?)", iterationIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, assignment := range assignments {
+		ids = append(ids, assignment.IssueID)
+	}
+
+	if issues, err = app.GetIssuesByID(clients, ids); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*BacklogIssue)
+	for _, issue := range issues {
+		byID[issue.ID] = issue
+	}
+
+	assigned := make(map[int64][]*BacklogIssue)
+	for _, assignment := range assignments {
+		if issue, ok := byID[assignment.IssueID]; ok {
+			assigned[assignment.IterationID] = append(assigned[assignment.IterationID], issue)
+		}
+	}
+
+	velocity = ComputeVelocity(iterations, assigned, window)
+	velocity.WorkspaceID = workspace.ID
+
+	if milestone == "" {
+		return velocity, nil
+	}
+
+	if issues, err = app.QueryMilestoneIssues(clients, workspace, milestone, githubv4.IssueFilters{
+		States: &[]githubv4.IssueState{githubv4.IssueStateOpen},
+	}); err != nil {
+		return nil, err
+	}
+
+	velocity.Forecast = &VelocityForecast{Milestone: milestone}
+
+	for _, issue := range issues {
+		velocity.Forecast.RemainingIssues++
+
+		if issue.Estimate == nil {
+			velocity.Forecast.UnestimatedIssues++
+			continue
+		}
+
+		velocity.Forecast.RemainingPoints += *issue.Estimate
+	}
+
+	velocity.Forecast.Iterations = ForecastIterations(velocity.Forecast.RemainingPoints, velocity.AveragePoints)
+
+	return velocity, nil
+}
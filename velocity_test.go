@@ -0,0 +1,47 @@
+package issues
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeVelocity(t *testing.T) {
+	start := time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+	two, three, five := 2.0, 3.0, 5.0
+	inTime, late := start.AddDate(0, 0, 5), start.AddDate(0, 0, 20)
+
+	iterations := []*Iteration{
+		{ID: 1, Name: "Sprint 1", Start: start, End: start.AddDate(0, 0, 14)},
+		{ID: 2, Name: "Sprint 2", Start: start.AddDate(0, 0, 14), End: start.AddDate(0, 0, 28)},
+		{ID: 3, Name: "Sprint 3", Start: start.AddDate(0, 0, 28), End: start.AddDate(0, 0, 42)},
+	}
+
+	issues := map[int64][]*BacklogIssue{
+		1: {{Estimate: &five, ClosedAt: &inTime}, {Estimate: &three, ClosedAt: &late}, {ClosedAt: &inTime}},
+		2: {{Estimate: &three, ClosedAt: &late}},
+		3: {{Estimate: &two}, {Estimate: &five, ClosedAt: &inTime}},
+	}
+
+	velocity := ComputeVelocity(iterations, issues, 2)
+
+	first := velocity.Iterations[0]
+	if first.CommittedIssues != 3 || first.CompletedIssues != 2 || first.CommittedPoints != 8 || first.CompletedPoints != 5 || first.RollingAverage != 5 {
+		t.Errorf("Unexpected velocity of first iteration %+v", first)
+	}
+
+	if last := velocity.Iterations[2]; last.CommittedIssues != 2 || last.CompletedIssues != 0 {
+		t.Errorf("Expected issues closed before the iteration not to be completed, got %+v", last)
+	}
+
+	if velocity.Iterations[1].RollingAverage != 4 || velocity.Iterations[2].RollingAverage != 1.5 || velocity.AveragePoints != 1.5 {
+		t.Errorf("Unexpected rolling averages %+v", velocity)
+	}
+
+	if iterations := ForecastIterations(10, velocity.AveragePoints); iterations == nil || *iterations != 7 {
+		t.Errorf("Unexpected forecast %v", iterations)
+	}
+
+	if iterations := ForecastIterations(10, 0); iterations != nil {
+		t.Errorf("Expected no forecast without velocity, got %d", *iterations)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// BacklogColumns contains the columns of the backlog a view can display
+var BacklogColumns = []string{
+	"repository",
+	"number",
+	"title",
+	"state",
+	"labels",
+	"assignees",
+	"milestone",
+	"reactions",
+	"createdAt",
+	"updatedAt",
+	"rank",
+	"estimate",
+	"url",
+}
+
+// FieldColumnPrefix is the prefix of columns displaying a custom field, e.g. field:severity
+const FieldColumnPrefix = "field:"
+
+// View is a named backlog query of a workspace, that is either private to its owner or shared
+// with all members of the workspace
+type View struct {
+	ID          int64          `json:"id"`
+	WorkspaceID int64          `json:"workspaceID"`
+	OwnerID     int64          `json:"ownerID"`
+	Name        string         `json:"name"`
+	Query       string         `json:"query"`
+	Sort        string         `json:"sort"`
+	Columns     pq.StringArray `json:"columns" gorm:"type:text[]"`
+	Shared      bool           `json:"shared"`
+}
+
+// BacklogQuery parses the query of the view including its sort order. The query can be further
+// refined, which also allows to override the sort order
+func (v *View) BacklogQuery(refinement string) (*BacklogQuery, error) {
+	query := v.Query
+
+	if v.Sort != "" {
+		query = fmt.Sprintf("%s sort:%s", query, v.Sort)
+	}
+
+	return ParseBacklogQuery(fmt.Sprintf("%s %s", query, refinement))
+}
+
+// Validate checks, whether the view can be stored
+func (v *View) Validate() (err error) {
+	v.Name = strings.TrimSpace(v.Name)
+
+	if v.Name == "" {
+		return fmt.Errorf("%w: view name must not be empty", ErrValidationFailed)
+	}
+
+	if _, err = v.BacklogQuery(""); err != nil {
+		return err
+	}
+
+	for _, column := range v.Columns {
+		if !isBacklogColumn(column) {
+			return fmt.Errorf("%w: unknown column %s", ErrValidationFailed, column)
+		}
+	}
+
+	return nil
+}
+
+func isBacklogColumn(column string) bool {
+	if strings.HasPrefix(column, FieldColumnPrefix) {
+		return len(column) > len(FieldColumnPrefix)
+	}
+
+	for _, c := range BacklogColumns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetViews returns all views of the workspace, that are visible to the user, i.e. the views owned
+// by the user and the ones shared with the workspace
+func (app *Application) GetViews(workspaceID int64, userID int64) ([]*View, error) {
+	return app.db.GetViews("workspace_id = ? AND (owner_id = ? OR shared = ?)", workspaceID, userID, true)
+}
+
+// GetView returns the view, if it is visible to the user
+func (app *Application) GetView(workspaceID int64, viewID int64, userID int64) (view *View, err error) {
+	var views []*View
+
+	if views, err = app.db.GetViews("id = ? AND workspace_id = ? AND (owner_id = ? OR shared = ?)", viewID, workspaceID, userID, true); err != nil {
+		return nil, err
+	}
+
+	if len(views) == 0 {
+		return nil, nil
+	}
+
+	return views[0], nil
+}
+
+// CreateView validates and stores a new view owned by the user
+func (app *Application) CreateView(workspaceID int64, userID int64, view *View) (err error) {
+	if err = app.validateView(workspaceID, view); err != nil {
+		return err
+	}
+
+	view.ID = 0
+	view.WorkspaceID = workspaceID
+	view.OwnerID = userID
+
+	return app.db.Insert(view)
+}
+
+// UpdateView validates and stores the modified view. Only owners of the view are allowed to modify it
+func (app *Application) UpdateView(view *View, userID int64) (err error) {
+	if view.OwnerID != userID {
+		return fmt.Errorf("%w: only the owner can modify view %d", ErrAccessDenied, view.ID)
+	}
+
+	if err = app.validateView(view.WorkspaceID, view); err != nil {
+		return err
+	}
+
+	_, err = app.db.Update(view)
+
+	return
+}
+
+// validateView validates the view including the custom fields referenced in its query
+func (app *Application) validateView(workspaceID int64, view *View) (err error) {
+	var query *BacklogQuery
+
+	if err = view.Validate(); err != nil {
+		return err
+	}
+
+	if query, err = view.BacklogQuery(""); err != nil {
+		return err
+	}
+
+	if err = app.ValidateBacklogQuery(workspaceID, query); err != nil {
+		return err
+	}
+
+	return app.ValidateBacklogColumns(workspaceID, view.Columns)
+}
+
+// DeleteView deletes the view. Shared views can also be deleted by maintainers of the workspace
+func (app *Application) DeleteView(view *View, userID int64, role Role) (err error) {
+	if view.OwnerID != userID && !(view.Shared && role.Includes(RoleMaintainer)) {
+		return fmt.Errorf("%w: view %d cannot be deleted", ErrAccessDenied, view.ID)
+	}
+
+	return app.db.Delete(view)
+}
@@ -16,24 +16,35 @@ package issues
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/google/go-github/v29/github"
 )
 
+// ErrValidationFailed is returned, if a request to modify an object contains invalid data
+var ErrValidationFailed = errors.New("Validation failed")
+
+// MaxWorkspaceNameLength is the maximum length of the name of a workspace
+const MaxWorkspaceNameLength = 100
+
 type RepositoryRefArray []int64
 
 type Workspace struct {
 	ID            int64              `json:"id"`
 	Name          string             `json:"name"`
 	RepositoryIDs RepositoryRefArray `json:"repositoryIDs" gorm:"type:integer[]"`
+	// CalendarToken protects the calendar feed of the workspace, which is disabled if empty
+	CalendarToken string `json:"-"`
 }
 
+// Relationship connects two issues of the same repository by their numbers. Relationships stored
+// before their repository was recorded have the repository ID 0 and are ignored
 type Relationship struct {
+	RepositoryID int64  `json:"repositoryId" gorm:"primary_key;auto_increment:false;not null;default:0"`
 	IssueID      int64  `josn:"issueId" gorm:"primary_key;auto_increment:false"`
 	OtherIssueID int64  `json:"otherIssueId" gorm:"primary_key;auto_increment:false"`
 	Type         string `json:"type"`
@@ -57,6 +68,12 @@ func (r *RepositoryRefArray) Scan(src interface{}) error {
 
 	s = strings.ReplaceAll(strings.ReplaceAll(string(u), "{", ""), "}", "")
 
+	// an empty array would otherwise result in one empty element
+	if s == "" {
+		*r = RepositoryRefArray{}
+		return nil
+	}
+
 	// split array
 	array := strings.Split(s, ",")
 	for _, v := range array {
@@ -72,52 +89,191 @@ func (r *RepositoryRefArray) Scan(src interface{}) error {
 	return nil
 }
 
+// Value converts the array into the PostgreSQL array representation
+func (r RepositoryRefArray) Value() (driver.Value, error) {
+	var elements []string
+
+	for _, v := range r {
+		elements = append(elements, strconv.FormatInt(v, 10))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(elements, ",")), nil
+}
+
+// Contains checks, whether the array contains the specified repository
+func (r RepositoryRefArray) Contains(repositoryID int64) bool {
+	for _, v := range r {
+		if v == repositoryID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks, whether the workspace can be stored
+func (w *Workspace) Validate() error {
+	w.Name = strings.TrimSpace(w.Name)
+
+	if w.Name == "" {
+		return fmt.Errorf("%w: workspace name must not be empty", ErrValidationFailed)
+	}
+
+	if len(w.Name) > MaxWorkspaceNameLength {
+		return fmt.Errorf("%w: workspace name must not be longer than %d characters", ErrValidationFailed, MaxWorkspaceNameLength)
+	}
+
+	return nil
+}
+
 func (app *Application) GetWorkspace(workspaceID int64) (*Workspace, error) {
 	return app.db.GetWorkspace(workspaceID)
 }
 
-type issue struct {
-	Number int
-	Title  string
+// CreateWorkspace validates and creates a new workspace. The user of the clients becomes its owner
+func (app *Application) CreateWorkspace(clients *GitHubClients, workspace *Workspace) (err error) {
+	if err = workspace.Validate(); err != nil {
+		return err
+	}
+
+	// the ID is assigned by the database
+	workspace.ID = 0
+
+	if workspace.RepositoryIDs == nil {
+		workspace.RepositoryIDs = RepositoryRefArray{}
+	}
+
+	if err = app.db.Insert(workspace); err != nil {
+		return err
+	}
+
+	return app.db.Insert(&WorkspaceMember{
+		WorkspaceID: workspace.ID,
+		UserID:      clients.User.GetID(),
+		Login:       clients.User.GetLogin(),
+		Role:        RoleOwner,
+	})
+}
+
+// UpdateWorkspace validates and stores the modified workspace
+func (app *Application) UpdateWorkspace(workspace *Workspace) (err error) {
+	if err = workspace.Validate(); err != nil {
+		return err
+	}
+
+	_, err = app.db.Update(workspace)
+
+	return
 }
 
-// GetBacklog retrieves all issues from all workspaces that do not have a milestone
-// associated with it. This needs to query the database as well as the GitHub
-// GraphQL endpoint
-func (app *Application) GetBacklog(clients *GitHubClients, workspaceID int64) (interface{}, error) {
-	// just some fun for now
-	/*var q struct {
-		Repository struct {
-			Issue struct {
-				Nodes []issue
-			} `graphql:"issues(last: 100, filterBy: {milestone: null, states: OPEN})"`
-		} `graphql:"repository(owner: $repositoryOwner, name: $repositoryName)"`
+// DeleteWorkspace deletes the workspace with the specified ID
+func (app *Application) DeleteWorkspace(workspaceID int64) (err error) {
+	var (
+		iterations []*Iteration
+		fields     []*CustomField
+	)
+
+	if workspaceID == 0 {
+		return fmt.Errorf("%w: invalid workspace ID", ErrValidationFailed)
 	}
 
-	variables := map[string]interface{}{
-		"repositoryOwner": githubv4.String("oxisto"),
-		"repositoryName":  githubv4.String("aybaze"),
+	if err = app.db.Delete(&WorkspaceMember{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
 	}
 
-	err := clients.V4.Query(context.Background(), &q, variables)
-	if err != nil {
+	if err = app.db.Delete(&BacklogRank{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	if err = app.db.Delete(&View{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	if err = app.db.Delete(&BoardColumn{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	if err = app.db.Delete(&WorkspaceMilestone{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	if err = app.db.Delete(&WorkspaceLabel{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	if iterations, err = app.GetIterations(workspaceID); err != nil {
+		return err
+	}
+
+	for _, iteration := range iterations {
+		if err = app.DeleteIteration(iteration); err != nil {
+			return err
+		}
+	}
+
+	if fields, err = app.GetCustomFields(workspaceID); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if err = app.DeleteCustomField(field); err != nil {
+			return err
+		}
+	}
+
+	if err = app.db.Delete(&BurndownSnapshot{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	if err = app.db.Delete(&StaleSettings{}, "workspace_id = ?", workspaceID); err != nil {
+		return err
+	}
+
+	return app.db.Delete(&Workspace{ID: workspaceID})
+}
+
+// AddWorkspaceRepository adds a repository to the workspace. The repository needs to be
+// accessible using the supplied clients
+func (app *Application) AddWorkspaceRepository(clients *GitHubClients, workspace *Workspace, repositoryID int64) (err error) {
+	var resp *github.Response
+
+	if workspace.RepositoryIDs.Contains(repositoryID) {
 		return nil
 	}
 
-	return q.Repository.Issue.Nodes*/
-	start := time.Now()
-	options := github.IssueListByRepoOptions{
-		Milestone: "none",
-		State:     "open",
+	if _, resp, err = clients.V3.Repositories.GetByID(context.Background(), repositoryID); err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return fmt.Errorf("%w: repository %d does not exist", ErrValidationFailed, repositoryID)
+		}
+
+		return fmt.Errorf("Could not retrieve repository %d: %w", repositoryID, err)
 	}
 
-	issues, _, err := clients.V3.Issues.ListByRepo(context.Background(), "oxisto", "aybaze", &options)
+	if err = app.checkStaleRepository(clients, workspace, repositoryID); err != nil {
+		return err
+	}
 
-	end := time.Now()
+	workspace.RepositoryIDs = append(workspace.RepositoryIDs, repositoryID)
 
-	duration := end.Sub(start)
+	return app.UpdateWorkspace(workspace)
+}
 
-	log.Infof("call to GitHub took %+v", duration)
+// RemoveWorkspaceRepository removes a repository from the workspace
+func (app *Application) RemoveWorkspaceRepository(workspace *Workspace, repositoryID int64) (err error) {
+	var repositoryIDs = RepositoryRefArray{}
 
-	return issues, err
+	for _, v := range workspace.RepositoryIDs {
+		if v != repositoryID {
+			repositoryIDs = append(repositoryIDs, v)
+		}
+	}
+
+	workspace.RepositoryIDs = repositoryIDs
+
+	return app.UpdateWorkspace(workspace)
+}
+
+type issue struct {
+	Number int
+	Title  string
 }
@@ -0,0 +1,22 @@
+package issues
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepositoryRefArray(t *testing.T) {
+	var r RepositoryRefArray
+
+	if err := r.Scan([]uint8("{}")); err != nil || len(r) != 0 {
+		t.Errorf("Expected empty array, got %v (%v)", r, err)
+	}
+
+	if err := r.Scan([]uint8("{1,42}")); err != nil || !reflect.DeepEqual(r, RepositoryRefArray{1, 42}) {
+		t.Errorf("Unexpected array %v (%v)", r, err)
+	}
+
+	if v, _ := r.Value(); v != "{1,42}" {
+		t.Errorf("Unexpected value %v", v)
+	}
+}
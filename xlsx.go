@@ -0,0 +1,163 @@
+// Copyright 2019 Christian Banse
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package issues
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// XLSXSheet is the name of the only worksheet of an XLSX export
+const XLSXSheet = "Issues"
+
+// xlsxParts are the parts of the workbook besides the worksheet. They are the same for all exports
+var xlsxParts = []struct {
+	Name    string
+	Content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + XLSXSheet + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+const xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const xlsxSheetEnd = `</sheetData></worksheet>`
+
+// xlsxTable writes a workbook with a single worksheet. The rows are compressed and written to the
+// worksheet as they come, so that the workbook is not buffered as a whole. All cells are inline
+// strings, so that no value is ever interpreted as a formula
+type xlsxTable struct {
+	zip        *zip.Writer
+	compressor *flate.Writer
+	sheet      io.Writer
+	rows       int
+}
+
+// NewXLSXTable returns a table writer, which writes an Office Open XML workbook
+func NewXLSXTable(w io.Writer) TableWriter {
+	t := &xlsxTable{zip: zip.NewWriter(w)}
+
+	// we need to keep the compressor of the worksheet, so that its rows can be flushed
+	t.zip.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		var err error
+
+		t.compressor, err = flate.NewWriter(w, flate.DefaultCompression)
+
+		return t.compressor, err
+	})
+
+	return t
+}
+
+// start writes all parts of the workbook and the beginning of the worksheet
+func (t *xlsxTable) start() (err error) {
+	var part io.Writer
+
+	for _, p := range xlsxParts {
+		if part, err = t.zip.Create(p.Name); err != nil {
+			return err
+		}
+
+		if _, err = io.WriteString(part, p.Content); err != nil {
+			return err
+		}
+	}
+
+	if t.sheet, err = t.zip.Create("xl/worksheets/sheet1.xml"); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(t.sheet, xlsxSheetStart)
+
+	return err
+}
+
+// WriteRow writes the values as row of inline strings
+func (t *xlsxTable) WriteRow(values []string) (err error) {
+	if t.sheet == nil {
+		if err = t.start(); err != nil {
+			return err
+		}
+	}
+
+	t.rows++
+
+	if _, err = fmt.Fprintf(t.sheet, `<row r="%d">`, t.rows); err != nil {
+		return err
+	}
+
+	for i, value := range values {
+		if _, err = fmt.Fprintf(t.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumnName(i), t.rows); err != nil {
+			return err
+		}
+
+		if err = xml.EscapeText(t.sheet, []byte(value)); err != nil {
+			return err
+		}
+
+		if _, err = io.WriteString(t.sheet, `</t></is></c>`); err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(t.sheet, `</row>`)
+
+	return err
+}
+
+// Flush writes all compressed rows to the underlying writer
+func (t *xlsxTable) Flush() (err error) {
+	if t.compressor != nil {
+		if err = t.compressor.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return t.zip.Flush()
+}
+
+// Close completes the worksheet and the workbook
+func (t *xlsxTable) Close() (err error) {
+	if t.sheet == nil {
+		if err = t.start(); err != nil {
+			return err
+		}
+	}
+
+	if _, err = io.WriteString(t.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+
+	return t.zip.Close()
+}
+
+// xlsxColumnName returns the name of the column with the zero-based index, e.g. A, Z or AA
+func xlsxColumnName(i int) (name string) {
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+
+	return name
+}
@@ -0,0 +1,64 @@
+package issues
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestWriteBacklogXLSX(t *testing.T) {
+	issues := []*BacklogIssue{
+		{Repository: "aybaze/api", Number: 1, Title: "=cmd|' /C calc'!A0 <script>"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBacklog(NewXLSXTable(&buf), issues, []string{"repository", "number", "title"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Could not open workbook: %s", err)
+	}
+
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("Could not open %s: %s", file.Name, err)
+		}
+
+		b, _ := ioutil.ReadAll(r)
+		r.Close()
+
+		parts[file.Name] = string(b)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Expected workbook to contain %s", name)
+		}
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+
+	for _, expected := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">repository</t></is></c>`,
+		`<c r="C2" t="inlineStr"><is><t xml:space="preserve">=cmd|&#39; /C calc&#39;!A0 &lt;script&gt;</t></is></c></row>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Errorf("Expected worksheet to contain %q, got %q", expected, sheet)
+		}
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	for i, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if name := xlsxColumnName(i); name != expected {
+			t.Errorf("Expected column %d to be named %s, got %s", i, expected, name)
+		}
+	}
+}